@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/freeze/freeze"
+)
+
+// castHeader is the first line of an asciicast v2 recording.
+// https://docs.asciinema.org/manual/asciicast/v2/
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Command   string            `json:"command,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// castEvent is an event of a recording: output of the terminal, for "o".
+type castEvent struct {
+	Time float64
+	Type string
+	Data string
+}
+
+// castRecorder writes the output of a command as an asciicast v2 recording,
+// timing every write since the recorder was created.
+type castRecorder struct {
+	mu    sync.Mutex
+	f     *os.File
+	w     *bufio.Writer
+	start time.Time
+	// partial is the start of a UTF-8 sequence cut off by the last write,
+	// since events must be valid UTF-8.
+	partial []byte
+	err     error
+}
+
+// newCastRecorder creates the recording at path and writes its header.
+func newCastRecorder(path string, header castHeader) (*castRecorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+	r := &castRecorder{f: f, w: bufio.NewWriter(f), start: time.Now()}
+	header.Version = 2
+	header.Timestamp = r.start.Unix()
+	if err := json.NewEncoder(r.w).Encode(header); err != nil {
+		_ = f.Close()
+		return nil, err //nolint: wrapcheck
+	}
+	return r, nil
+}
+
+// Write records p as output. It never fails, so that a recording that can't
+// be written doesn't stop the capture; the error is returned by Close.
+func (r *castRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil || r.f == nil {
+		return len(p), nil
+	}
+
+	b := append(r.partial, p...)
+	n := len(b)
+	// keep an incomplete sequence at the end for the next write.
+	for i := 1; i < utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				n = len(b) - i
+			}
+			break
+		}
+	}
+	r.partial = append([]byte(nil), b[n:]...)
+	if n > 0 {
+		r.event(string(b[:n]))
+	}
+	return len(p), nil
+}
+
+// event writes an output event with the time since the start.
+func (r *castRecorder) event(data string) {
+	t := time.Since(r.start).Seconds()
+	line, err := json.Marshal([]any{math.Round(t*1e6) / 1e6, "o", data})
+	if err == nil {
+		_, err = fmt.Fprintf(r.w, "%s\n", line)
+	}
+	r.err = err
+}
+
+// Close writes what's left of the recording and closes it. Writes after
+// Close are ignored.
+func (r *castRecorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return r.err
+	}
+	if len(r.partial) > 0 && r.err == nil {
+		r.event(string(r.partial))
+	}
+	if err := r.w.Flush(); r.err == nil {
+		r.err = err
+	}
+	if err := r.f.Close(); r.err == nil {
+		r.err = err
+	}
+	r.f = nil
+	return r.err
+}
+
+// isCast returns whether the input file is an asciicast recording.
+func isCast(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".cast")
+}
+
+// readCast reads an asciicast v2 recording and returns its header and its
+// output events.
+func readCast(r io.Reader) (castHeader, []castEvent, error) {
+	var header castHeader
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return header, nil, err //nolint: wrapcheck
+		}
+		return header, nil, errors.New("empty recording")
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return header, nil, fmt.Errorf("invalid header: %w", err)
+	}
+	if header.Version != 2 {
+		return header, nil, fmt.Errorf("unsupported asciicast version %d, only version 2 is supported", header.Version)
+	}
+
+	var events []castEvent
+	for n := 2; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var fields []json.RawMessage
+		var event castEvent
+		if err := json.Unmarshal([]byte(line), &fields); err != nil || len(fields) != 3 {
+			return header, nil, fmt.Errorf("line %d: invalid event", n)
+		}
+		if err := json.Unmarshal(fields[0], &event.Time); err != nil {
+			return header, nil, fmt.Errorf("line %d: invalid time: %w", n, err)
+		}
+		if err := json.Unmarshal(fields[1], &event.Type); err != nil {
+			return header, nil, fmt.Errorf("line %d: invalid type: %w", n, err)
+		}
+		// only output is shown; input, markers and resizes are skipped.
+		if event.Type != "o" {
+			continue
+		}
+		if err := json.Unmarshal(fields[2], &event.Data); err != nil {
+			return header, nil, fmt.Errorf("line %d: invalid data: %w", n, err)
+		}
+		events = append(events, event)
+	}
+	return header, events, scanner.Err() //nolint: wrapcheck
+}
+
+// replayCast returns the output of a recording at config.At, and its frames
+// when config.Animate is set. The output wraps where the terminal of the
+// recording did.
+func replayCast(config *freeze.Config, input string) (string, []freeze.Frame, error) {
+	header, events, err := readCast(strings.NewReader(input))
+	if err != nil {
+		return "", nil, err
+	}
+	if config.At > 0 && (len(events) == 0 || events[0].Time > config.At.Seconds()) {
+		return "", nil, errors.New("--at is before the first event of the recording")
+	}
+	config.Language = "ansi"
+	if config.Wrap == 0 {
+		config.Wrap = header.Width
+	}
+	if config.Rows == 0 {
+		config.Rows = header.Height
+	}
+	var frames []freeze.Frame
+	if config.Animate {
+		frames = castFrames(events, config.At, config.AnimateInterval)
+	}
+	return castOutput(events, config.At), frames, nil
+}
+
+// castOutput returns the output of the recording up to at, or all of it when
+// at is 0.
+func castOutput(events []castEvent, at time.Duration) string {
+	var b strings.Builder
+	for _, e := range events {
+		if at > 0 && e.Time > at.Seconds() {
+			break
+		}
+		b.WriteString(e.Data)
+	}
+	return b.String()
+}
+
+// castFrames returns the frames of an animation of the recording up to at,
+// or all of it when at is 0. Events less than interval apart share a frame.
+func castFrames(events []castEvent, at, interval time.Duration) []freeze.Frame {
+	var frames []freeze.Frame
+	var b strings.Builder
+	var last float64
+	for _, e := range events {
+		if at > 0 && e.Time > at.Seconds() {
+			break
+		}
+		b.WriteString(e.Data)
+		n := len(frames)
+		if n > 0 && e.Time-last < interval.Seconds() {
+			frames[n-1].Input = b.String()
+			continue
+		}
+		if n > 0 {
+			frames[n-1].Delay = time.Duration(math.Round((e.Time - last) * float64(time.Second)))
+		}
+		frames = append(frames, freeze.Frame{Input: b.String(), Delay: finalFrameDelay})
+		last = e.Time
+	}
+	return frames
+}
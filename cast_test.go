@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/freeze/freeze"
+)
+
+func TestCastRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "demo.cast")
+	rec, err := newCastRecorder(path, castHeader{Width: 40, Height: 10, Command: "demo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "é" is split across writes, and recorded once it's complete.
+	for _, p := range []string{"h\xc3", "\xa9llo\r\n", "\xe2\x94"} {
+		if _, err := rec.Write([]byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint: errcheck
+	header, events, err := readCast(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != 2 || header.Width != 40 || header.Height != 10 || header.Command != "demo" {
+		t.Errorf("unexpected header %+v", header)
+	}
+	var data []string
+	for _, e := range events {
+		data = append(data, e.Data)
+	}
+	// an incomplete sequence at the end is replaced byte by byte.
+	want := []string{"h", "éllo\r\n", "\ufffd\ufffd"}
+	if strings.Join(data, "|") != strings.Join(want, "|") {
+		t.Errorf("expected events %q, got %q", want, data)
+	}
+}
+
+func TestReadCastErrors(t *testing.T) {
+	for name, cast := range map[string]string{
+		"empty":          "",
+		"invalid header": "version 2",
+		"version 1":      `{"version": 1, "width": 80, "height": 24}`,
+		"invalid event":  "{\"version\": 2}\n[0.1, \"o\"]",
+		"invalid time":   "{\"version\": 2}\n[\"soon\", \"o\", \"hi\"]",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := readCast(strings.NewReader(cast)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestCastOutput(t *testing.T) {
+	events := []castEvent{
+		{Time: 0.1, Type: "o", Data: "a"},
+		{Time: 0.15, Type: "o", Data: "b"},
+		{Time: 1, Type: "o", Data: "c"},
+		{Time: 2.5, Type: "o", Data: "d"},
+	}
+
+	for _, tc := range []struct {
+		at   time.Duration
+		want string
+	}{
+		{0, "abcd"},
+		{time.Second, "abc"},
+		{50 * time.Millisecond, ""},
+	} {
+		if got := castOutput(events, tc.at); got != tc.want {
+			t.Errorf("expected output at %s to be %q, got %q", tc.at, tc.want, got)
+		}
+	}
+
+	frames := castFrames(events, 2*time.Second, 100*time.Millisecond)
+	if len(frames) != 2 {
+		t.Fatalf("expected 2 frames, got %d", len(frames))
+	}
+	if frames[0].Input != "ab" || frames[0].Delay != 900*time.Millisecond {
+		t.Errorf("unexpected first frame %+v", frames[0])
+	}
+	if frames[1].Input != "abc" || frames[1].Delay != finalFrameDelay {
+		t.Errorf("unexpected last frame %+v", frames[1])
+	}
+}
+
+func TestReplayCastBeforeFirstEvent(t *testing.T) {
+	input := `{"version": 2, "width": 40, "height": 10}
+[0.5, "o", "hello"]
+`
+	config := freeze.Config{At: 100 * time.Millisecond}
+	_, _, err := replayCast(&config, input)
+	if err == nil || !strings.Contains(err.Error(), "--at is before the first event") {
+		t.Fatalf("expected --at to be reported, got %v", err)
+	}
+
+	config.At = time.Second
+	output, _, err := replayCast(&config, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Wrap != 40 || config.Rows != 10 {
+		t.Errorf("expected the 40x10 terminal of the recording, got %dx%d", config.Wrap, config.Rows)
+	}
+	if output != "hello" {
+		t.Errorf("expected hello, got %q", output)
+	}
+}
@@ -0,0 +1,138 @@
+package freeze
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// minContrast is the contrast ratio adapted text keeps with its background,
+// the WCAG AA level for normal text.
+const minContrast = 4.5
+
+// colorAdapter remaps the colors of terminal output, so that output captured
+// on a dark terminal stays legible on a light theme and the other way round.
+// Text keeps enough contrast with its background, and with quantize, every
+// color is first mapped to the closest color of the palette.
+type colorAdapter struct {
+	quantize bool
+
+	// text and backgrounds are the colors text and backgrounds are mapped
+	// to: the palette, with the default color of the text or the terminal.
+	text, backgrounds []string
+}
+
+// newColorAdapter returns the adapter for the adapt mode of the palette, or
+// nil when colors are left as they are.
+func newColorAdapter(mode string, colors terminalColors, fg, bg string) *colorAdapter {
+	switch mode {
+	case "contrast":
+		return &colorAdapter{}
+	case "palette":
+		return &colorAdapter{
+			quantize:    true,
+			text:        append([]string{fg}, colors[:16]...),
+			backgrounds: append([]string{bg}, colors[:16]...),
+		}
+	default:
+		return nil
+	}
+}
+
+// background returns the color of a background.
+func (a *colorAdapter) background(bg string) string {
+	if !a.quantize || bg == "" {
+		return bg
+	}
+	return nearest(bg, a.backgrounds)
+}
+
+// foreground returns the color of text drawn on the background bg.
+func (a *colorAdapter) foreground(fg, bg string) string {
+	if fg == "" {
+		return fg
+	}
+	if a.quantize {
+		fg = nearest(fg, a.text)
+	}
+	return withContrast(fg, bg)
+}
+
+// nearest returns the color of the palette closest to c.
+func nearest(c string, palette []string) string {
+	target, err := parseHexColor(c)
+	if err != nil {
+		return c
+	}
+	best, bestDistance := c, math.Inf(1)
+	for _, p := range palette {
+		pc, err := parseHexColor(p)
+		if err != nil {
+			continue
+		}
+		if d := colorDistance(target, pc); d < bestDistance {
+			best, bestDistance = p, d
+		}
+	}
+	return best
+}
+
+// withContrast returns fg, mixed with black or white until it has enough
+// contrast with bg.
+func withContrast(fg, bg string) string {
+	f, err := parseHexColor(fg)
+	if err != nil {
+		return fg
+	}
+	b, err := parseHexColor(bg)
+	if err != nil || contrast(f, b) >= minContrast {
+		return fg
+	}
+
+	// move towards whichever of black and white stands out more.
+	towards := color.NRGBA{A: 0xff}
+	white := color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	if contrast(white, b) > contrast(towards, b) {
+		towards = white
+	}
+	mixed := f
+	for i := 1; i <= 20 && contrast(mixed, b) < minContrast; i++ {
+		mixed = mix(f, towards, float64(i)/20)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", mixed.R, mixed.G, mixed.B)
+}
+
+func mix(a, b color.NRGBA, t float64) color.NRGBA {
+	channel := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*t)) //nolint: gosec
+	}
+	return color.NRGBA{R: channel(a.R, b.R), G: channel(a.G, b.G), B: channel(a.B, b.B), A: 0xff}
+}
+
+// contrast returns the WCAG contrast ratio of two colors, from 1 to 21.
+func contrast(a, b color.NRGBA) float64 {
+	la, lb := luminance(a), luminance(b)
+	return (max(la, lb) + 0.05) / (min(la, lb) + 0.05)
+}
+
+// luminance returns the relative luminance of a color.
+func luminance(c color.NRGBA) float64 {
+	linear := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(c.R) + 0.7152*linear(c.G) + 0.0722*linear(c.B)
+}
+
+// colorDistance approximates how different two colors look, weighting the
+// channels by how sensitive the eye is to them.
+func colorDistance(a, b color.NRGBA) float64 {
+	mean := (float64(a.R) + float64(b.R)) / 2
+	dr := float64(a.R) - float64(b.R)
+	dg := float64(a.G) - float64(b.G)
+	db := float64(a.B) - float64(b.B)
+	return math.Sqrt((2+mean/256)*dr*dr + 4*dg*dg + (2+(255-mean)/256)*db*db)
+}
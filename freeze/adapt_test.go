@@ -0,0 +1,39 @@
+package freeze
+
+import "testing"
+
+func TestColorAdapter(t *testing.T) {
+	const light, dark = "#ffffff", "#171717"
+	colors := palettes["github"].colors()
+
+	tests := []struct {
+		name     string
+		mode     string
+		fg, bg   string
+		expected string
+	}{
+		{"legible", "contrast", "#24292e", light, "#24292e"},
+		{"white on light", "contrast", "#ffffff", light, "#737373"},
+		{"black on dark", "contrast", "#000000", dark, "#808080"},
+		{"default color", "contrast", "", light, ""},
+		{"nearest", "palette", "#d83b4a", light, "#d73a49"},
+		{"nearest legible", "palette", "#ffff00", light, "#8e6f06"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			a := newColorAdapter(tc.mode, colors, "#24292e", light)
+			if got := a.foreground(tc.fg, tc.bg); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+
+	if a := newColorAdapter("none", colors, "", ""); a != nil {
+		t.Error("expected no adapter")
+	}
+	a := newColorAdapter("palette", colors, "#24292e", light)
+	if got := a.background("#fefefe"); got != light {
+		t.Errorf("expected the background to map to the terminal, got %s", got)
+	}
+}
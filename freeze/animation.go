@@ -0,0 +1,183 @@
+package freeze
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Frame is a snapshot of terminal output in an animation.
+type Frame struct {
+	// Input is everything the terminal printed up to the frame.
+	Input string
+	// Delay is how long the frame is shown.
+	Delay time.Duration
+}
+
+// EncodeAnimation renders every frame with the configuration and encodes
+// them as an animation: an animated GIF for "gif", an APNG for "png" and
+// "apng" and an animated WebP for "webp".
+//
+// Frames are padded to the size of the largest one, so the window keeps the
+// same size throughout the animation.
+func EncodeAnimation(ctx context.Context, config Config, frames []Frame) ([]byte, error) {
+	if len(frames) == 0 {
+		return nil, ErrNoInput
+	}
+
+	format := config.OutputFormat()
+	switch format {
+	case "gif", "png", "apng", "webp":
+	default:
+		return nil, fmt.Errorf("%w: %q cannot be animated", ErrUnknownFormat, format)
+	}
+
+	var enc animationEncoder
+	switch format {
+	case "gif":
+		enc = &gifEncoder{}
+	case "webp":
+		enc = &webpEncoder{quality: config.Quality, lossless: config.Lossless}
+	default:
+		enc = &apngEncoder{}
+	}
+
+	rasterizers, err := Rasterizers(config.Renderer)
+	if err != nil {
+		return nil, err
+	}
+	// resvg would start anew for every frame, so keep one running for all
+	// of them.
+	worker := &resvgWorker{}
+	defer worker.Close() //nolint: errcheck
+	for i, r := range rasterizers {
+		if r == Resvg {
+			rasterizers[i] = worker
+		}
+	}
+
+	var prev *image.NRGBA
+	for i, input := range padFrames(frames, config.Wrap, config.Rows) {
+		doc, err := Render(ctx, config, strings.NewReader(input))
+		if err != nil {
+			return nil, err
+		}
+		img, r, err := Rasterize(ctx, config, doc, rasterizers...)
+		if err != nil {
+			return nil, err
+		}
+		// draw the rest of the frames the same way, rather than trying the
+		// rasterizers that failed again.
+		rasterizers = []Rasterizer{r}
+
+		// padding only fails to even out the frames in corner cases, like
+		// tabs, so cover those by drawing every frame on a canvas the size
+		// of the first one.
+		cur := toNRGBA(img)
+		changed := cur.Bounds()
+		if prev != nil {
+			if cur.Bounds() != prev.Bounds() {
+				canvas := image.NewNRGBA(prev.Bounds())
+				draw.Draw(canvas, canvas.Bounds(), cur, image.Point{}, draw.Src)
+				cur = canvas
+			}
+			changed = changedBounds(prev, cur)
+		}
+		if err := enc.addFrame(cur, changed, frames[i].Delay); err != nil {
+			return nil, err
+		}
+		prev = cur
+	}
+	return enc.encode()
+}
+
+// animationEncoder encodes frames into an animation. Every frame after the
+// first only replaces the part of the previous frame that changed.
+type animationEncoder interface {
+	addFrame(img *image.NRGBA, changed image.Rectangle, delay time.Duration) error
+	encode() ([]byte, error)
+}
+
+// padFrames pads the screen of every frame with empty lines and columns to
+// the number of lines and the width of the largest one. Frames are measured
+// by the screen they leave in a terminal of wrap columns and rows, since
+// output that redraws lines takes less room than it prints.
+func padFrames(frames []Frame, wrap, rows int) []string {
+	inputs := make([]string, len(frames))
+	lines := make([]int, len(frames))
+	widths := make([]int, len(frames))
+	scrollback := make([]int, len(frames))
+	var maxLines, maxWidth int
+	for i, f := range frames {
+		inputs[i] = strings.TrimSuffix(f.Input, "\n")
+		t := runTerminal(inputs[i], wrap, rows, terminalColors{})
+		screen := t.screen()
+		for _, row := range screen {
+			widths[i] = max(widths[i], ansi.StringWidth(screenText([][]cell{row})))
+		}
+		lines[i] = len(screen)
+		scrollback[i] = len(t.visibleScrollback())
+		maxLines = max(maxLines, lines[i])
+		maxWidth = max(maxWidth, widths[i])
+	}
+
+	for i := range inputs {
+		if lines[i] == maxLines && widths[i] == maxWidth {
+			continue
+		}
+		// print an unstyled space in the bottom right corner, which is
+		// past the end of the screen, and put the cursor back.
+		row, up := maxLines-scrollback[i], 0
+		pad := ansi.ResetStyle + ansi.SaveCursor
+		if rows > 0 && row > rows {
+			// the corner is below the screen, so scroll the screen up to
+			// it, and the cursor with it.
+			up = row - rows
+			row = rows
+			pad += ansi.CursorPosition(1, rows) + strings.Repeat("\n", up)
+		}
+		pad += ansi.CursorPosition(maxWidth, row) + " " + ansi.RestoreCursor
+		if up > 0 {
+			pad += ansi.CursorUp(up)
+		}
+		inputs[i] += pad
+	}
+	return inputs
+}
+
+// toNRGBA converts the image to an NRGBA image with its origin at 0, 0.
+func toNRGBA(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	if nrgba, ok := img.(*image.NRGBA); ok && b.Min == (image.Point{}) {
+		return nrgba
+	}
+	nrgba := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(nrgba, nrgba.Bounds(), img, b.Min, draw.Src)
+	return nrgba
+}
+
+// changedBounds returns the smallest rectangle containing every pixel that
+// differs between the images, which must have the same bounds.
+func changedBounds(prev, cur *image.NRGBA) image.Rectangle {
+	var r image.Rectangle
+	b := cur.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		i := cur.PixOffset(b.Min.X, y)
+		for x := b.Min.X; x < b.Max.X; x, i = x+1, i+4 {
+			if cur.Pix[i] != prev.Pix[i] || cur.Pix[i+1] != prev.Pix[i+1] ||
+				cur.Pix[i+2] != prev.Pix[i+2] || cur.Pix[i+3] != prev.Pix[i+3] {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if r.Empty() {
+		// frames need at least one pixel.
+		return image.Rect(b.Min.X, b.Min.Y, b.Min.X+1, b.Min.Y+1)
+	}
+	return r
+}
@@ -0,0 +1,171 @@
+package freeze
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestPadFrames(t *testing.T) {
+	frames := []Frame{
+		{Input: "$ ls\n"},
+		{Input: "$ ls\nfreeze.go  main.go\n"},
+		{Input: "$ ls\n\x1b[31mfreeze.go\x1b[0m\n"},
+		// lines drawn over take no more room than what is left of them.
+		{Input: "$ ls\nlisting" + strings.Repeat(".", 30) + "\r\x1b[Kmain.go\n"},
+	}
+	inputs := padFrames(frames, 0, 0)
+	for i, input := range inputs {
+		screen := screenText(emulate(input, 0, 0, terminalColors{}))
+		if want := "$ ls\n"; !strings.HasPrefix(screen, want) {
+			t.Errorf("frame %d: expected the screen to start with %q, got %q", i, want, screen)
+		}
+		lines := strings.Split(screen, "\n")
+		if len(lines) != 2 || ansi.StringWidth(lines[1]) != 18 {
+			t.Errorf("frame %d: expected 2 lines and 18 columns, got %q", i, screen)
+		}
+	}
+	if inputs[1] != "$ ls\nfreeze.go  main.go" {
+		t.Errorf("expected the largest frame to be left as is, got %q", inputs[1])
+	}
+}
+
+func TestPadFramesHeight(t *testing.T) {
+	// the last frame scrolled its first lines off a screen of 2 rows.
+	frames := []Frame{
+		{Input: "$ seq 3\n"},
+		{Input: "$ seq 3\n1\n2\n3"},
+	}
+	for i, input := range padFrames(frames, 0, 2) {
+		screen := screenText(emulate(input, 0, 2, terminalColors{}))
+		if !strings.HasPrefix(screen, "$ seq 3\n") || strings.Count(screen, "\n") != 3 {
+			t.Errorf("frame %d: expected 4 lines starting with the command, got %q", i, screen)
+		}
+	}
+}
+
+func TestGIFTransparency(t *testing.T) {
+	// the second frame only clears a pixel in the middle of the first one.
+	first := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	draw.Draw(first, first.Bounds(), image.NewUniform(color.NRGBA{0xff, 0, 0, 0xff}), image.Point{}, draw.Src)
+	second := image.NewNRGBA(first.Bounds())
+	copy(second.Pix, first.Pix)
+	second.SetNRGBA(2, 2, color.NRGBA{})
+
+	var e gifEncoder
+	if err := e.addFrame(first, first.Bounds(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.addFrame(second, changedBounds(first, second), 0); err != nil {
+		t.Fatal(err)
+	}
+	b, err := e.encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := gif.DecodeAll(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// draw the frames like a viewer would.
+	canvas := image.NewNRGBA(first.Bounds())
+	for i, frame := range g.Image {
+		draw.Draw(canvas, frame.Rect, frame, frame.Rect.Min, draw.Over)
+		if i < len(g.Image)-1 && g.Disposal[i] == gif.DisposalBackground {
+			draw.Draw(canvas, frame.Rect, image.Transparent, image.Point{}, draw.Src)
+		}
+	}
+	if c := canvas.NRGBAAt(2, 2); c.A != 0 {
+		t.Fatalf("expected the cleared pixel to be transparent, got %v", c)
+	}
+	if c := canvas.NRGBAAt(1, 1); c != (color.NRGBA{0xff, 0, 0, 0xff}) {
+		t.Fatalf("expected the rest of the frame to be kept, got %v", c)
+	}
+}
+
+func TestEncodeAnimation(t *testing.T) {
+	frames := []Frame{
+		{Input: "$ echo hello\n", Delay: 100 * time.Millisecond},
+		{Input: "$ echo hello\nhello\n", Delay: time.Second},
+	}
+
+	config := DefaultConfig()
+	config.Language = "ansi"
+	config.Renderer = "native"
+
+	t.Run("gif", func(t *testing.T) {
+		config.Output = "out.gif"
+		b, err := EncodeAnimation(context.Background(), config, frames)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, err := gif.DecodeAll(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(g.Image) != 2 || g.Delay[0] != 10 || g.Delay[1] != 100 {
+			t.Fatalf("expected 2 frames with 10 and 100 delays, got %d with %v", len(g.Image), g.Delay)
+		}
+		if !g.Image[1].Rect.In(g.Image[0].Rect) || g.Image[1].Rect == g.Image[0].Rect {
+			t.Fatalf("expected the second frame to only cover the changed pixels, got %v", g.Image[1].Rect)
+		}
+	})
+
+	t.Run("apng", func(t *testing.T) {
+		config.Output = "out.png"
+		b, err := EncodeAnimation(context.Background(), config, frames)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := png.Decode(bytes.NewReader(b)); err != nil {
+			t.Fatal(err)
+		}
+		chunks := map[string]int{}
+		for i := len(pngSignature); i+8 <= len(b); {
+			n := int(binary.BigEndian.Uint32(b[i:]))
+			chunks[string(b[i+4:i+8])]++
+			i += 12 + n
+		}
+		if chunks["acTL"] != 1 || chunks["fcTL"] != 2 || chunks["IDAT"] != 1 || chunks["fdAT"] != 1 {
+			t.Fatalf("unexpected chunks %v", chunks)
+		}
+	})
+
+	t.Run("webp", func(t *testing.T) {
+		config.Output = "out.webp"
+		b, err := EncodeAnimation(context.Background(), config, frames)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b[:4]) != "RIFF" || string(b[8:12]) != "WEBP" || int(binary.LittleEndian.Uint32(b[4:])) != len(b)-8 {
+			t.Fatal("invalid RIFF header")
+		}
+		var chunks []string
+		for i := 12; i+8 <= len(b); {
+			n := int(binary.LittleEndian.Uint32(b[i+4:]))
+			chunks = append(chunks, string(b[i:i+4]))
+			i += 8 + n + n%2
+		}
+		if len(chunks) != 4 || chunks[0] != "VP8X" || chunks[1] != "ANIM" || chunks[2] != "ANMF" || chunks[3] != "ANMF" {
+			t.Fatalf("unexpected chunks %v", chunks)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		config.Output = "out.jpg"
+		if _, err := EncodeAnimation(context.Background(), config, frames); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
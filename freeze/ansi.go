@@ -0,0 +1,398 @@
+package freeze
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// faintOpacity is the opacity of faint text.
+const faintOpacity = 0.5
+
+// screenWriter writes the cells of a terminal screen into the text lines of
+// the SVG.
+type screenWriter struct {
+	scale  float64
+	svg    *etree.Element
+	config *Config
+	lines  []*etree.Element
+
+	// fg and bg are the default colors of the text and the terminal, which
+	// reverse video swaps.
+	fg, bg string
+
+	// adapt remaps the colors of the output to the theme, when set.
+	adapt *colorAdapter
+
+	// cursor is drawn in cursorColor, when set.
+	cursor      *cursor
+	cursorColor string
+}
+
+// write writes every row into its line, with a tspan for every run of cells
+// with the same style and a rect behind every run of cells with the same
+// background. Hyperlinked cells are wrapped in a link.
+func (p *screenWriter) write(rows [][]cell) {
+	for row, cells := range rows {
+		if row >= len(p.lines) {
+			return
+		}
+		line := p.lines[row]
+
+		parent := line
+		var span *etree.Element
+		var style cellStyle
+		var link string
+		for col, c := range cells {
+			if c.width == 0 {
+				continue
+			}
+			if p.cursor != nil && p.cursor.shape == cursorBlock && p.cursor.y == row && p.cursor.x == col {
+				c.style = p.cursorStyle(c.style)
+			}
+			if c.link != link {
+				// hyperlinks wrap the spans of their cells.
+				parent = line
+				if c.link != "" {
+					parent = line.CreateElement("a")
+					parent.CreateAttr("href", c.link)
+				}
+				link = c.link
+				span = nil
+			}
+			if c.link != "" && p.config.UnderlineLinks && c.style.underline == underlineNone {
+				c.style.underline = underlineSingle
+			}
+			if span == nil || c.style != style || c.width > 1 {
+				span = p.span(c.style)
+				if c.width > 1 {
+					// wide characters are drawn with a fallback font, narrower
+					// than the two cells they take.
+					span.CreateAttr("dx", fmt.Sprintf("%.2fpx", (p.config.Font.Size/5)*p.scale))
+				}
+				parent.AddChild(span)
+				style = c.style
+			}
+			span.SetText(span.Text() + c.content)
+			if c.width > 1 {
+				// the next character starts a new span.
+				span = nil
+			}
+		}
+
+		eachRun(cells, func(style cellStyle) string {
+			_, bg := p.colors(style)
+			return bg
+		}, func(col, n int, bg string) {
+			if bg != "" {
+				p.background(row, col, n, bg)
+			}
+		})
+
+		eachRun(cells, p.underline, func(col, n int, u underline) {
+			if u.style != underlineNone {
+				p.drawUnderline(line, col, n, u)
+			}
+		})
+
+		if p.cursor != nil && p.cursor.y == row {
+			p.drawCursor(row, p.cursor.x, p.cursor.shape, p.cursorColor)
+		}
+	}
+}
+
+// eachRun calls fn for every run of cells with the same key.
+func eachRun[K comparable](cells []cell, key func(cellStyle) K, fn func(col, n int, key K)) {
+	start := 0
+	for col := 1; col <= len(cells); col++ {
+		k := key(cells[start].style)
+		if col < len(cells) && key(cells[col].style) == k {
+			continue
+		}
+		fn(start, col-start, k)
+		start = col
+	}
+}
+
+// underline is an underline drawn as a path, rather than with the
+// text-decoration of the text.
+type underline struct {
+	style underlineStyle
+	color string
+}
+
+// underline returns the underline to draw for cells with the style. Plain
+// underlines in the color of the text are left to text-decoration.
+func (p *screenWriter) underline(style cellStyle) underline {
+	if style.conceal || style.underline == underlineNone ||
+		(style.underline == underlineSingle && style.underlineColor == "") {
+		return underline{}
+	}
+	fg, bg := p.colors(style)
+	c := style.underlineColor
+	if p.adapt != nil {
+		c = p.adapt.foreground(c, cmp.Or(bg, p.bg))
+	}
+	return underline{style: style.underline, color: cmp.Or(c, fg, p.fg)}
+}
+
+// drawUnderline draws the underline below n cells of the line, starting at
+// col.
+func (p *screenWriter) drawUnderline(line *etree.Element, col, n int, u underline) {
+	charWidth := p.scale * (p.config.Font.Size / fontHeightToWidthRatio)
+	x1 := float64(p.config.Margin[left]+p.config.Padding[left]) + float64(col)*charWidth
+	if p.config.ShowLineNumbers {
+		x1 += float64(p.config.Font.Size) * 3
+	}
+	x2 := x1 + float64(n)*charWidth
+	thickness := p.config.Font.Size * p.scale / 16
+	y := parseLength(line.SelectAttrValue("y", "0"), 0) + p.config.Font.Size*p.scale/8
+
+	var d string
+	switch u.style {
+	case underlineDouble:
+		d = fmt.Sprintf("M%.2f %.2fH%.2fM%.2f %.2fH%.2f", x1, y-thickness, x2, x1, y+thickness, x2)
+	case underlineCurly:
+		// a wave of quadratic curves, two to a cell.
+		half := charWidth / 2
+		amplitude := thickness * 1.5
+		d = fmt.Sprintf("M%.2f %.2fQ%.2f %.2f %.2f %.2f", x1, y, x1+half/2, y-amplitude, x1+half, y)
+		for x := x1 + 2*half; x <= x2+0.01; x += half {
+			d += fmt.Sprintf("T%.2f %.2f", x, y)
+		}
+	default:
+		d = fmt.Sprintf("M%.2f %.2fH%.2f", x1, y, x2)
+	}
+
+	path := etree.NewElement("path")
+	path.CreateAttr("d", d)
+	path.CreateAttr("fill", "none")
+	path.CreateAttr("stroke", u.color)
+	path.CreateAttr("stroke-width", fmt.Sprintf("%.2f", thickness))
+	switch u.style {
+	case underlineDotted:
+		path.CreateAttr("stroke-dasharray", fmt.Sprintf("%.2f", thickness))
+	case underlineDashed:
+		path.CreateAttr("stroke-dasharray", fmt.Sprintf("%.2f %.2f", thickness*3, thickness*2))
+	}
+	path.CreateAttr("data-underline", u.style.String())
+	p.svg.AddChild(path)
+}
+
+// colors returns the colors of the text and the background of cells with
+// the style. Empty colors are the defaults of the terminal.
+func (p *screenWriter) colors(style cellStyle) (string, string) {
+	fg, bg := style.fg, style.bg
+	if style.reverse {
+		fg, bg = cmp.Or(style.bg, p.bg), cmp.Or(style.fg, p.fg)
+	}
+	if p.adapt != nil {
+		bg = p.adapt.background(bg)
+		fg = p.adapt.foreground(fg, cmp.Or(bg, p.bg))
+	}
+	return fg, bg
+}
+
+func (p *screenWriter) span(style cellStyle) *etree.Element {
+	span := etree.NewElement("tspan")
+	span.CreateAttr("xml:space", "preserve")
+	if fg, _ := p.colors(style); fg != "" {
+		span.CreateAttr("fill", fg)
+	}
+	if style.bold {
+		span.CreateAttr("font-weight", "bold")
+	}
+	if style.italic {
+		span.CreateAttr("font-style", "italic")
+	}
+	var decorations []string
+	if style.underline == underlineSingle && style.underlineColor == "" {
+		decorations = append(decorations, "underline")
+	}
+	if style.strike {
+		decorations = append(decorations, "line-through")
+	}
+	if len(decorations) > 0 {
+		span.CreateAttr("text-decoration", strings.Join(decorations, " "))
+	}
+	switch {
+	case style.conceal:
+		// concealed text is still there to copy, like in a terminal.
+		span.CreateAttr("fill-opacity", "0")
+	case style.faint:
+		span.CreateAttr("fill-opacity", fmt.Sprintf("%.2f", faintOpacity))
+	}
+	if style.blink && !style.conceal {
+		// blinking text blinks in SVG viewers that play animations, and is
+		// shown in everything else.
+		animate := span.CreateElement("animate")
+		animate.CreateAttr("attributeName", "visibility")
+		animate.CreateAttr("values", "visible;hidden")
+		animate.CreateAttr("dur", "1s")
+		animate.CreateAttr("calcMode", "discrete")
+		animate.CreateAttr("repeatCount", "indefinite")
+	}
+	return span
+}
+
+const fontHeightToWidthRatio = 1.68
+
+// background draws a rect behind width cells of the row, starting at col.
+func (p *screenWriter) background(row, col, width int, fill string) {
+	rect := etree.NewElement("rect")
+	rect.CreateAttr("fill", fill)
+
+	x, y, charWidth, height := p.cell(row, col)
+	rect.CreateAttr("x", fmt.Sprintf("%.2fpx", x))
+	rect.CreateAttr("y", fmt.Sprintf("%.2fpx", y))
+	rect.CreateAttr("height", fmt.Sprintf("%.2fpx", height))
+
+	w := (float64(width) + 0.5) * charWidth
+	rect.CreateAttr("width", fmt.Sprintf("%.5fpx", w))
+	p.svg.InsertChildAt(0, rect)
+}
+
+// cell returns the position and the size of the cell at col of the row.
+func (p *screenWriter) cell(row, col int) (x, y, width, height float64) {
+	topOffset := p.config.Padding[top] + p.config.Margin[top] + (((p.config.Font.Size + p.config.LineHeight) / 5) * p.scale)
+	rowMultiplier := p.config.Font.Size * p.config.LineHeight
+
+	width = p.scale * (p.config.Font.Size / fontHeightToWidthRatio)
+	x = float64(col)*width + float64(p.config.Margin[left]+p.config.Padding[left])
+	if p.config.ShowLineNumbers {
+		x += float64(p.config.Font.Size) * 3
+	}
+	y = float64(row)*rowMultiplier + topOffset
+	return x, y, width, p.config.Font.Size*p.config.LineHeight + 1
+}
+
+// sgr updates the pen of the terminal with the parameters of an SGR
+// sequence. Every attribute is kept until it is turned off or reset, so
+// sequences only change the attributes they name.
+func (t *terminal) sgr(params ansi.Params) {
+	if len(params) == 0 {
+		// zero params means reset
+		t.pen = cellStyle{}
+		return
+	}
+
+	var i int
+	for i < len(params) {
+		v := params[i].Param(0)
+		switch v {
+		case 0:
+			t.pen = cellStyle{}
+		case 1:
+			t.pen.bold = true
+		case 2:
+			t.pen.faint = true
+		case 3:
+			t.pen.italic = true
+		case 4:
+			t.pen.underline = underlineSingle
+			if params[i].HasMore() {
+				// 4:0 to 4:5 pick the style of the underline.
+				i++
+				if style := underlineStyle(params[i].Param(1)); style <= underlineDashed {
+					t.pen.underline = style
+				}
+			}
+		case 5, 6:
+			t.pen.blink = true
+		case 7:
+			t.pen.reverse = true
+		case 8:
+			t.pen.conceal = true
+		case 9:
+			t.pen.strike = true
+		case 21:
+			t.pen.underline = underlineDouble
+		case 22:
+			t.pen.bold = false
+			t.pen.faint = false
+		case 23:
+			t.pen.italic = false
+		case 24:
+			t.pen.underline = underlineNone
+		case 25:
+			t.pen.blink = false
+		case 27:
+			t.pen.reverse = false
+		case 28:
+			t.pen.conceal = false
+		case 29:
+			t.pen.strike = false
+		case 30, 31, 32, 33, 34, 35, 36, 37:
+			t.pen.fg = t.colors[v-30]
+		case 90, 91, 92, 93, 94, 95, 96, 97:
+			t.pen.fg = t.colors[v-90+8]
+		case 38:
+			var c string
+			if c, i = t.extendedColor(params, i); c != "" {
+				t.pen.fg = c
+			}
+		case 39:
+			t.pen.fg = ""
+		case 40, 41, 42, 43, 44, 45, 46, 47:
+			t.pen.bg = t.colors[v-40]
+		case 100, 101, 102, 103, 104, 105, 106, 107:
+			t.pen.bg = t.colors[v-100+8]
+		case 48:
+			var c string
+			if c, i = t.extendedColor(params, i); c != "" {
+				t.pen.bg = c
+			}
+		case 49:
+			t.pen.bg = ""
+		case 58:
+			var c string
+			if c, i = t.extendedColor(params, i); c != "" {
+				t.pen.underlineColor = c
+			}
+		case 59:
+			t.pen.underlineColor = ""
+		}
+		// skip the sub-parameters nothing above read, like the 3 of 1:3.
+		for i < len(params)-1 && params[i].HasMore() {
+			i++
+		}
+		i++
+	}
+}
+
+// extendedColor returns the 256 color or true color set by the 38, 48 or 58
+// parameter at i, and the index of its last parameter. Both the semicolon
+// form, 38;2;r;g;b, and the colon form, 38:2::r:g:b, are supported. The
+// color is empty when the color space is unknown.
+func (t *terminal) extendedColor(params ansi.Params, i int) (string, int) {
+	colon := params[i].HasMore()
+	i++
+	if i >= len(params) {
+		return "", i
+	}
+	switch params[i].Param(0) {
+	case 5:
+		if i+1 >= len(params) {
+			return "", i
+		}
+		n := params[i+1].Param(0)
+		if n < 0 || n >= len(t.colors) {
+			return "", i + 1
+		}
+		return t.colors[n], i + 1
+	case 2:
+		if colon && params[i].HasMore() && i+4 < len(params) && params[i+3].HasMore() {
+			// skip the color space id of the colon form.
+			i++
+		}
+		if i+3 >= len(params) {
+			return "", len(params) - 1
+		}
+		r, g, b := params[i+1].Param(0), params[i+2].Param(0), params[i+3].Param(0)
+		return fmt.Sprintf("#%02x%02x%02x", clamp(r, 0, 255), clamp(g, 0, 255), clamp(b, 0, 255)), i + 3
+	}
+	return "", i
+}
@@ -0,0 +1,109 @@
+package freeze
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"time"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// apngEncoder encodes an animated PNG. Viewers without APNG support show
+// the first frame.
+//
+// Every frame is written as 8-bit RGBA, since the frames of an APNG share
+// the color type of the header.
+type apngEncoder struct {
+	width, height int
+	frames        int
+	sequence      uint32
+	buf           bytes.Buffer
+}
+
+func (e *apngEncoder) addFrame(img *image.NRGBA, changed image.Rectangle, delay time.Duration) error {
+	if e.frames == 0 {
+		e.width, e.height = img.Bounds().Dx(), img.Bounds().Dy()
+	}
+
+	// frame control: sequence number, size and offset, delay, dispose and
+	// blend operations. The frame replaces the pixels it covers.
+	ms := clamp(int(delay/time.Millisecond), 0, 0xffff)
+	fctl := make([]byte, 26)
+	binary.BigEndian.PutUint32(fctl[0:], e.sequence)
+	binary.BigEndian.PutUint32(fctl[4:], uint32(changed.Dx()))   //nolint: gosec
+	binary.BigEndian.PutUint32(fctl[8:], uint32(changed.Dy()))   //nolint: gosec
+	binary.BigEndian.PutUint32(fctl[12:], uint32(changed.Min.X)) //nolint: gosec
+	binary.BigEndian.PutUint32(fctl[16:], uint32(changed.Min.Y)) //nolint: gosec
+	binary.BigEndian.PutUint16(fctl[20:], uint16(ms))            //nolint: gosec
+	binary.BigEndian.PutUint16(fctl[22:], 1000)
+	writePNGChunk(&e.buf, "fcTL", fctl)
+	e.sequence++
+
+	data, err := pngImageData(img, changed)
+	if err != nil {
+		return err
+	}
+	if e.frames == 0 {
+		writePNGChunk(&e.buf, "IDAT", data)
+	} else {
+		seq := binary.BigEndian.AppendUint32(nil, e.sequence)
+		writePNGChunk(&e.buf, "fdAT", append(seq, data...))
+		e.sequence++
+	}
+	e.frames++
+	return nil
+}
+
+func (e *apngEncoder) encode() ([]byte, error) {
+	var out bytes.Buffer
+	out.Write(pngSignature)
+
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(e.width))  //nolint: gosec
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(e.height)) //nolint: gosec
+	ihdr[8] = 8                                            // bit depth
+	ihdr[9] = 6                                            // RGBA
+	writePNGChunk(&out, "IHDR", ihdr)
+
+	// animation control: number of frames and plays, 0 plays forever.
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(e.frames)) //nolint: gosec
+	writePNGChunk(&out, "acTL", actl)
+
+	out.Write(e.buf.Bytes())
+	writePNGChunk(&out, "IEND", nil)
+	return out.Bytes(), nil
+}
+
+// pngImageData compresses the rectangle of the image into PNG image data,
+// without filtering.
+func pngImageData(img *image.NRGBA, r image.Rectangle) ([]byte, error) {
+	var buf bytes.Buffer
+	z := zlib.NewWriter(&buf)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		i := img.PixOffset(r.Min.X, y)
+		if _, err := z.Write([]byte{0}); err != nil {
+			return nil, err //nolint: wrapcheck
+		}
+		if _, err := z.Write(img.Pix[i : i+4*r.Dx()]); err != nil {
+			return nil, err //nolint: wrapcheck
+		}
+	}
+	if err := z.Close(); err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+	return buf.Bytes(), nil
+}
+
+func writePNGChunk(buf *bytes.Buffer, name string, data []byte) {
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(data))) //nolint: gosec
+	crc := crc32.NewIEEE()
+	_, _ = crc.Write([]byte(name))
+	_, _ = crc.Write(data)
+	buf.WriteString(name)
+	buf.Write(data)
+	_ = binary.Write(buf, binary.BigEndian, crc.Sum32())
+}
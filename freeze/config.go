@@ -0,0 +1,143 @@
+package freeze
+
+import "time"
+
+// Config is the configuration options for a screenshot.
+type Config struct {
+	Input string `json:",omitempty" arg:"" help:"Code to screenshot." optional:""`
+
+	// Window
+	Background string    `json:"background" help:"Apply a background fill." short:"b" placeholder:"#171717" group:"Window"`
+	Margin     []float64 `json:"margin" help:"Apply margin to the window." short:"m" placeholder:"0" group:"Window"`
+	Padding    []float64 `json:"padding" help:"Apply padding to the code." short:"p" placeholder:"0" group:"Window"`
+	Window     bool      `json:"window" help:"Display window controls." group:"Window"`
+	Width      float64   `json:"width" help:"Width of terminal window." short:"W" group:"Window"`
+	Height     float64   `json:"height" help:"Height of terminal window." short:"H" group:"Window"`
+
+	// Settings
+	Version     bool   `json:"version" help:"Display Freeze's version." short:"v" group:"Settings"`
+	Config      string `json:"config,omitempty" help:"Base configuration file or template." short:"c" group:"Settings" default:"default" placeholder:"base"`
+	Interactive bool   `hidden:"" json:",omitempty" help:"Use an interactive form for configuration options." short:"i" group:"Settings"`
+	Language    string `json:"language,omitempty" help:"Language of code file." short:"l" group:"Settings" placeholder:"go"`
+	Theme       string `json:"theme" help:"Theme to use for syntax highlighting." short:"t" group:"Settings" placeholder:"charm"`
+	Wrap        int    `json:"wrap" help:"Wrap lines at a specific width." short:"w" group:"Settings" default:"0" placeholder:"80"`
+	// Rows is the height of the terminal ANSI output was written to, which
+	// it scrolls within. Without it, the screen grows with the output.
+	Rows int `json:"-" kong:"-"`
+
+	UnderlineLinks bool   `json:"underline_links" help:"Underline the hyperlinks of terminal output." group:"Settings"`
+	Cursor         string `json:"cursor,omitempty" help:"Draw the cursor of terminal output: none, auto, block, underline or bar." group:"Settings" enum:",none,auto,block,underline,bar" default:"none" placeholder:"auto"`
+
+	Output              string        `json:"output,omitempty" help:"Output location for {{.svg}}, {{.png}}, {{.webp}}, {{.jpg}}, {{.avif}}, {{.gif}}, {{.pdf}}, or {{.html}}, or - for stdout." short:"o" group:"Settings" default:"" placeholder:"freeze.svg"`
+	Format              string        `json:"format,omitempty" help:"Output format ({{.svg}}, {{.png}}, {{.webp}}, {{.jpg}}, {{.avif}}, {{.gif}}, {{.pdf}} or {{.html}}), instead of the output extension." short:"f" group:"Settings" placeholder:"png"`
+	Quality             int           `json:"quality" help:"Quality of {{.jpg}} and {{.avif}} output (1-100, defaults to 90), or palette size of {{.webp}} output, which is lossless without it." group:"Settings" placeholder:"90"`
+	Lossless            bool          `json:"lossless" help:"Use lossless compression for {{.webp}} and {{.avif}} output." group:"Settings"`
+	Renderer            string        `json:"renderer,omitempty" help:"Renderers to try in order for raster output: auto, rsvg, resvg or native." group:"Settings" default:"auto" placeholder:"rsvg,resvg"`
+	Preview             bool          `json:"-" help:"Preview the image in the terminal with the Kitty, iTerm2 or Sixel graphics protocol." group:"Settings"`
+	PreviewProtocol     string        `json:"-" help:"Graphics protocol of the preview: auto, kitty, iterm2 or sixel." group:"Settings" default:"auto" enum:"auto,kitty,iterm2,sixel" prefix:"preview." name:"protocol" placeholder:"auto"`
+	Execute             string        `json:"-" help:"Capture output of command execution." short:"x" group:"Settings" default:""`
+	ExecuteTimeout      time.Duration `json:"-" help:"Execution timeout." group:"Settings" default:"10s" prefix:"execute." name:"timeout" hidden:""`
+	ExecuteCols         int           `json:"-" help:"Columns of the terminal the command runs in, instead of the size of this one." group:"Settings" prefix:"execute." name:"cols" placeholder:"80"`
+	ExecuteRows         int           `json:"-" help:"Rows of the terminal the command runs in, instead of the size of this one." group:"Settings" prefix:"execute." name:"rows" placeholder:"24"`
+	ExecuteEnv          []string      `json:"-" help:"Set an environment variable of the command. Repeat for more." group:"Settings" prefix:"execute." name:"env" sep:"none" placeholder:"KEY=VALUE"`
+	ExecuteDir          string        `json:"-" help:"Working directory of the command." group:"Settings" prefix:"execute." name:"dir" placeholder:"."`
+	ExecuteCleanEnv     bool          `json:"-" help:"Run the command with a clean environment: PATH, HOME, USER, LANG, TMPDIR, TERM=xterm-256color and COLORTERM=truecolor." group:"Settings" prefix:"execute." name:"clean-env"`
+	ExecuteScript       string        `json:"-" help:"Drive the command with a script of keys to type, waits and captures." group:"Settings" prefix:"execute." name:"script" placeholder:"script.txt"`
+	ExecutePrompt       string        `json:"-" help:"Show the command after this prompt, with {user}, {host}, {cwd} and {dir} placeholders." group:"Settings" prefix:"execute." name:"prompt" placeholder:"{dir} $ "`
+	ExecutePromptColor  string        `json:"-" help:"Color of the prompt: #rrggbb or a palette index." group:"Settings" default:"5" prefix:"execute." name:"prompt-color" placeholder:"#8056ff"`
+	ExecuteStderr       bool          `json:"-" help:"Capture stderr apart from stdout, in its own color." group:"Settings" prefix:"execute." name:"stderr"`
+	ExecuteStderrColor  string        `json:"-" help:"Color of stderr and of failed exit statuses: #rrggbb or a palette index." group:"Settings" default:"1" prefix:"execute." name:"stderr-color" placeholder:"#ff5f87"`
+	ExecuteStderrMarker string        `json:"-" help:"Marker at the start of the lines of stderr." group:"Settings" prefix:"execute." name:"stderr-marker" placeholder:"!"`
+	ExecuteExitStatus   bool          `json:"-" help:"Show the exit status below the output, instead of failing when the command does." group:"Settings" prefix:"execute." name:"exit-status"`
+	Transcript          string        `json:"-" help:"Run the commands of a file one after the other in a shell, and capture the transcript." group:"Settings" placeholder:"steps.sh"`
+	TranscriptShell     string        `json:"-" help:"Shell the commands of --transcript run in." group:"Settings" default:"sh" prefix:"transcript." name:"shell" placeholder:"bash"`
+	ExecuteRecord       string        `json:"-" help:"Save an asciicast v2 recording of the command, to replay or render later." group:"Settings" prefix:"execute." name:"record" placeholder:"demo.cast"`
+	At                  time.Duration `json:"-" help:"Render a {{.cast}} recording as it was at this time, instead of at the end." group:"Settings" placeholder:"3.2s"`
+
+	// Animation
+	Animate         bool          `json:"-" help:"Record the output of --execute or a {{.cast}} recording as an animated {{.gif}}, {{.png}} or {{.webp}}." group:"Settings"`
+	AnimateInterval time.Duration `json:"-" help:"Interval between frames of the animation." group:"Settings" default:"100ms" prefix:"animate." name:"interval" placeholder:"100ms"`
+
+	// Decoration
+	Border Border `json:"border" embed:"" prefix:"border." group:"Border"`
+	Shadow Shadow `json:"shadow" embed:"" prefix:"shadow." help:"add a shadow to the window" short:"s" group:"Shadow"`
+
+	// Font
+	Font Font `json:"font" embed:"" prefix:"font." group:"Font"`
+
+	// Palette
+	Palette Palette `json:"palette" embed:"" prefix:"palette." group:"Palette"`
+
+	// Line
+	LineHeight      float64 `json:"line_height" help:"Line height relative to font size." group:"Line" placeholder:"1.2"`
+	Lines           []int   `json:"-" help:"Lines to capture (start,end)." group:"Line" placeholder:"0,-1" value:"0,-1"`
+	ShowLineNumbers bool    `json:"show_line_numbers" help:"" group:"Line" placeholder:"false"`
+}
+
+// Shadow is the configuration options for a drop shadow.
+type Shadow struct {
+	Blur float64 `json:"blur" help:"Shadow Gaussian Blur." placeholder:"0"`
+	X    float64 `json:"x" help:"Shadow offset {{x}} coordinate." placeholder:"0"`
+	Y    float64 `json:"y" help:"Shadow offset {{y}} coordinate." placeholder:"0"`
+}
+
+// Border is the configuration options for a window border.
+type Border struct {
+	Radius float64 `json:"radius" help:"Corner radius of window." short:"r" placeholder:"0"`
+	Width  float64 `json:"width" help:"Border width thickness." placeholder:"1"`
+	Color  string  `json:"color" help:"Border color." placeholder:"#000"`
+}
+
+// Font is the configuration options for a font.
+type Font struct {
+	Family    string  `json:"family" help:"Font family to use for code." placeholder:"monospace"`
+	File      string  `json:"file" help:"Font file to embed." placeholder:"monospace.ttf"`
+	Size      float64 `json:"size" help:"Font size to use for code." placeholder:"14"`
+	Ligatures bool    `json:"ligatures" help:"Use ligatures in the font." placeholder:"true" value:"true" negatable:""`
+}
+
+// DefaultConfig returns the configuration of the built-in base template.
+func DefaultConfig() Config {
+	return Config{
+		Theme:      "charm",
+		Background: "#171717",
+		Padding:    []float64{20, 40, 20, 20},
+		Margin:     []float64{0},
+		Border:     Border{Color: "#515151"},
+		Font: Font{
+			Family:    "JetBrains Mono",
+			Size:      defaultFontSize,
+			Ligatures: true,
+		},
+		LineHeight:     defaultLineHeight,
+		Renderer:       "auto",
+		ExecuteTimeout: 10 * time.Second,
+	}
+}
+
+// ExpandPadding expands 1, 2 or 4 padding values (like CSS) into the four
+// sides (top, right, bottom, left) and scales them.
+func ExpandPadding(p []float64, scale float64) []float64 {
+	switch len(p) {
+	case 1:
+		return []float64{p[top] * scale, p[top] * scale, p[top] * scale, p[top] * scale}
+	case 2:
+		return []float64{p[top] * scale, p[right] * scale, p[top] * scale, p[right] * scale}
+	case 4:
+		return []float64{p[top] * scale, p[right] * scale, p[bottom] * scale, p[left] * scale}
+	default:
+		return []float64{0, 0, 0, 0}
+	}
+}
+
+// ExpandMargin expands margin values the same way as ExpandPadding.
+var ExpandMargin = ExpandPadding
+
+type side int
+
+const (
+	top    side = 0
+	right  side = 1
+	bottom side = 2
+	left   side = 3
+)
@@ -0,0 +1,84 @@
+package freeze
+
+import (
+	"fmt"
+
+	"github.com/beevik/etree"
+)
+
+// drawnCursor returns the cursor to draw with the cursor option: the shape
+// the output left it in for "auto", or the shape it names. It returns false
+// when the option is "none" or the output hid the cursor.
+func drawnCursor(option string, c cursor) (cursor, bool) {
+	switch option {
+	case "auto":
+	case "block":
+		c.shape = cursorBlock
+	case "underline":
+		c.shape = cursorUnderline
+	case "bar":
+		c.shape = cursorBar
+	default:
+		return c, false
+	}
+	return c, !c.hidden
+}
+
+// withCursorCell returns the rows with a cell under the cursor, adding blank
+// cells to its row when the cursor is past its end. A cursor on the second
+// half of a wide character is moved to its start.
+func withCursorCell(rows [][]cell, c cursor) ([][]cell, cursor) {
+	if c.y < 0 || c.y >= len(rows) {
+		return rows, c
+	}
+	row := rows[c.y]
+	for len(row) <= c.x {
+		row = append(row, blankCell(cellStyle{}))
+	}
+	for c.x > 0 && row[c.x].width == 0 {
+		c.x--
+	}
+	rows[c.y] = row
+	return rows, c
+}
+
+// cursorStyle returns the style of the cell under a block cursor: the text
+// in the color of the background, on the color of the cursor.
+func (p *screenWriter) cursorStyle(style cellStyle) cellStyle {
+	_, bg := p.colors(style)
+	style.fg = bg
+	if style.fg == "" {
+		style.fg = p.bg
+	}
+	style.reverse = false
+	return style
+}
+
+// drawCursor draws the cursor over the cell at col of the row, behind the
+// text and above the backgrounds.
+func (p *screenWriter) drawCursor(row, col int, shape cursorShape, fill string) {
+	x, y, width, height := p.cell(row, col)
+	thickness := p.config.Font.Size * p.scale / 8
+	switch shape {
+	case cursorUnderline:
+		y += height - thickness
+		height = thickness
+	case cursorBar:
+		width = thickness
+	}
+
+	rect := etree.NewElement("rect")
+	rect.CreateAttr("fill", fill)
+	rect.CreateAttr("x", fmt.Sprintf("%.2fpx", x))
+	rect.CreateAttr("y", fmt.Sprintf("%.2fpx", y))
+	rect.CreateAttr("width", fmt.Sprintf("%.2fpx", width))
+	rect.CreateAttr("height", fmt.Sprintf("%.2fpx", height))
+	rect.CreateAttr("data-cursor", []string{"block", "underline", "bar"}[shape])
+
+	// backgrounds are the rects before the first line of text.
+	i := len(p.svg.Child)
+	if first := p.svg.SelectElement("text"); first != nil {
+		i = first.Index()
+	}
+	p.svg.InsertChildAt(i, rect)
+}
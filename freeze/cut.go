@@ -0,0 +1,63 @@
+package freeze
+
+import "strings"
+
+func cut(input string, window []int) string {
+	lines := strings.Split(input, "\n")
+	start, end, ok := cutWindow(len(lines), window)
+	if !ok {
+		return input
+	}
+	return strings.Join(lines[start:end], "\n")
+}
+
+// cutRows returns the rows of the screen in the window of lines.
+func cutRows(rows [][]cell, window []int) [][]cell {
+	start, end, ok := cutWindow(len(rows), window)
+	if !ok {
+		return rows
+	}
+	return rows[start:end]
+}
+
+// cutWindow returns the range of n lines in the window. It returns false
+// when the window covers every line.
+func cutWindow(n int, window []int) (int, int, bool) {
+	if len(window) == 0 {
+		return 0, n, false
+	}
+	if len(window) == 1 && window[0] == 0 {
+		return 0, n, false
+	}
+	if len(window) == 2 && window[0] == 0 && window[1] == -1 {
+		return 0, n, false
+	}
+
+	start := 0
+	end := n
+
+	switch len(window) {
+	case 1:
+		if window[0] > 0 {
+			start = window[0]
+		} else {
+			start = n + window[0] // add negative = subtract
+		}
+	case 2:
+		start = window[0]
+		end = window[1]
+	}
+
+	start = clamp(start, 0, n)
+	end = clamp(end+1, start, n)
+
+	if start == end && start < n {
+		return start, start + 1, true
+	}
+
+	return start, end, true
+}
+
+func clamp(n, low, high int) int {
+	return min(max(n, low), high)
+}
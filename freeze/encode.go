@@ -0,0 +1,115 @@
+package freeze
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"path/filepath"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// ErrUnknownFormat is returned when encoding to a format freeze does not
+// support.
+var ErrUnknownFormat = errors.New("unknown format")
+
+// Encode encodes a rendered document into the format returned by
+// Config.OutputFormat: PNG for "png" and "apng", WebP for "webp", JPEG for
+// "jpg" and "jpeg", AVIF for "avif", GIF for "gif", PDF for "pdf", a standalone
+// HTML page for "html" and SVG for "svg".
+//
+// Raster formats are drawn with Rasterize; use it and EncodeImage instead to
+// know which rasterizer drew the image.
+func Encode(ctx context.Context, config Config, doc *etree.Document) ([]byte, error) {
+	switch format := config.OutputFormat(); format {
+	case "pdf":
+		return encodePDF(config, doc)
+	case "html":
+		return encodeHTML(config, doc)
+	case "png", "apng", "webp", "jpg", "jpeg", "avif", "gif":
+		img, _, err := Rasterize(ctx, config, doc)
+		if err != nil {
+			return nil, err
+		}
+		return EncodeImage(ctx, config, img)
+	case "svg":
+		return doc.WriteToBytes() //nolint: wrapcheck
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+// EncodeImage encodes a rasterized image into the raster format returned by
+// Config.OutputFormat.
+func EncodeImage(ctx context.Context, config Config, img image.Image) ([]byte, error) {
+	switch format := config.OutputFormat(); format {
+	case "png", "apng":
+		return encodePNG(img)
+	case "gif":
+		return encodeGIF(img)
+	case "webp":
+		return encodeWebP(img, config.Quality, config.Lossless)
+	case "avif":
+		return encodeAVIF(ctx, img, config.Quality, config.Lossless)
+	case "jpg", "jpeg":
+		return encodeJPEG(img, config.Quality, config.Background)
+	default:
+		return nil, fmt.Errorf("%w: %q is not a raster format", ErrUnknownFormat, format)
+	}
+}
+
+// IsRaster reports whether the output file is encoded as a raster image
+// rather than an SVG.
+func IsRaster(output string) bool {
+	return isRasterFormat(Format(output))
+}
+
+func isRasterFormat(format string) bool {
+	switch format {
+	case "png", "apng", "webp", "jpg", "jpeg", "avif", "gif":
+		return true
+	default:
+		return false
+	}
+}
+
+// Format returns the format of the output file, based on its extension, e.g.
+// "png" or "pdf". Unknown extensions are written as "svg".
+func Format(output string) string {
+	switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(output), ".")); ext {
+	case "png", "apng", "webp", "jpg", "jpeg", "avif", "gif", "pdf", "html":
+		return ext
+	default:
+		return "svg"
+	}
+}
+
+// OutputFormat returns the format Encode uses: Config.Format if it is set,
+// and the format of the output file otherwise.
+func (c Config) OutputFormat() string {
+	if c.Format != "" {
+		return strings.ToLower(strings.TrimPrefix(c.Format, "."))
+	}
+	return Format(c.Output)
+}
+
+// Scale returns the factor Render scales the image by. Raster images are
+// drawn at 4 times their size to stay sharp, unless they have a fixed size.
+func (c Config) Scale() float64 {
+	if c.Width == 0 && c.Height == 0 && isRasterFormat(c.OutputFormat()) {
+		return 4
+	}
+	return 1
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+	return buf.Bytes(), nil
+}
@@ -1,4 +1,4 @@
-package main
+package freeze
 
 import (
 	"encoding/base64"
@@ -0,0 +1,335 @@
+// Package freeze generates images of code and terminal output.
+package freeze
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	formatter "github.com/alecthomas/chroma/v2/formatters/svg"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/beevik/etree"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/cellbuf"
+
+	"github.com/charmbracelet/freeze/svg"
+)
+
+const (
+	defaultFontSize   = 14.0
+	defaultLineHeight = 1.2
+)
+
+var (
+	// ErrNoInput is returned when there is nothing to render, e.g. the input
+	// is empty or the requested lines are out of bounds.
+	ErrNoInput = errors.New("no input")
+
+	// ErrUnknownLanguage is returned when the language of the input could not
+	// be detected and none was specified.
+	ErrUnknownLanguage = errors.New("unknown language")
+)
+
+// Render renders the input into an SVG document using the given
+// configuration.
+func Render(ctx context.Context, config Config, r io.Reader) (*etree.Document, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read input: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+	input := string(b)
+	lexer := detectLexer(config, input)
+
+	autoHeight := config.Height == 0
+	autoWidth := config.Width == 0
+
+	scale := config.Scale()
+
+	config.Margin = ExpandMargin(config.Margin, scale)
+	config.Padding = ExpandPadding(config.Padding, scale)
+
+	// adjust for 1-indexing
+	config.Lines = append([]int(nil), config.Lines...)
+	for i := range config.Lines {
+		config.Lines[i]--
+	}
+
+	strippedInput := ansi.Strip(input)
+	isAnsi := strings.ToLower(config.Language) == "ansi" || strippedInput != input
+
+	if !isAnsi && lexer == nil {
+		return nil, ErrUnknownLanguage
+	}
+
+	var screen [][]cell
+	var pal Palette
+	var cur *cursor
+	if isAnsi {
+		if input == "" {
+			return nil, ErrNoInput
+		}
+		pal, err = terminalPalette(config)
+		if err != nil {
+			return nil, err
+		}
+		// run the input through a terminal as wide as the character limit,
+		// and as tall as the one it was written to, and draw the screen it
+		// leaves behind.
+		t := runTerminal(input, config.Wrap, config.Rows, pal.colors())
+		rows := t.screen()
+		start, _, _ := cutWindow(len(rows), config.Lines)
+		screen = cutRows(rows, config.Lines)
+		if len(screen) == 0 {
+			return nil, ErrNoInput
+		}
+
+		if c, ok := drawnCursor(config.Cursor, t.cursor()); ok {
+			// the cursor can be anywhere past the end of its line, so the
+			// screen is sized with the cell under it.
+			c.y -= start
+			screen, c = withCursorCell(screen, c)
+			cur = &c
+		}
+		strippedInput = screenText(screen)
+	} else {
+		strippedInput = cut(strippedInput, config.Lines)
+
+		// wrap to character limit.
+		if config.Wrap > 0 {
+			strippedInput = cellbuf.Wrap(strippedInput, config.Wrap, "")
+			input = cellbuf.Wrap(input, config.Wrap, "")
+		}
+
+		input = cut(input, config.Lines)
+		if input == "" {
+			return nil, ErrNoInput
+		}
+	}
+
+	s, ok := styles.Registry[strings.ToLower(config.Theme)]
+	if s == nil || !ok {
+		s = charmStyle
+	}
+	if !s.Has(chroma.Background) {
+		s, err = s.Builder().Add(chroma.Background, "bg:"+config.Background).Build()
+		if err != nil {
+			return nil, fmt.Errorf("could not add background: %w", err)
+		}
+	}
+
+	// Create a token iterator.
+	var it chroma.Iterator
+	if isAnsi {
+		// For ANSI output, we'll inject our own SVG. For now, let's just strip the ANSI
+		// codes and print the text to properly size the input.
+		it = chroma.Literator(chroma.Token{Type: chroma.Text, Value: strippedInput})
+	} else {
+		it, err = chroma.Coalesce(lexer).Tokenise(nil, input)
+		if err != nil {
+			return nil, fmt.Errorf("could not lex file: %w", err)
+		}
+	}
+
+	// Format the code to an SVG.
+	options, err := fontOptions(&config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid font options: %w", err)
+	}
+
+	f := formatter.New(options...)
+	buf := &bytes.Buffer{}
+	err = f.Format(buf, s, it)
+	if err != nil {
+		return nil, fmt.Errorf("could not format: %w", err)
+	}
+
+	// Parse SVG (XML document)
+	doc := etree.NewDocument()
+	_, err = doc.ReadFrom(buf)
+	if err != nil {
+		return nil, fmt.Errorf("bad svg: %w", err)
+	}
+
+	elements := doc.ChildElements()
+	if len(elements) < 1 {
+		return nil, errors.New("bad svg: no elements")
+	}
+
+	image := elements[0]
+
+	hPadding := config.Padding[left] + config.Padding[right]
+	hMargin := config.Margin[left] + config.Margin[right]
+	vMargin := config.Margin[top] + config.Margin[bottom]
+	vPadding := config.Padding[top] + config.Padding[bottom]
+
+	terminal := image.SelectElement("rect")
+
+	w, h := svg.GetDimensions(image)
+
+	imageWidth := float64(w)
+	imageHeight := float64(h)
+
+	imageWidth *= scale
+	imageHeight *= scale
+
+	// chroma automatically calculates the height based on a font size of 14
+	// and a line height of 1.2
+	imageHeight *= (config.Font.Size / defaultFontSize)
+	imageHeight *= (config.LineHeight / defaultLineHeight)
+
+	terminalWidth := imageWidth
+	terminalHeight := imageHeight
+
+	if !autoWidth {
+		imageWidth = config.Width
+		terminalWidth = config.Width - hMargin
+	} else {
+		imageWidth += hMargin + hPadding
+		terminalWidth += hPadding
+	}
+
+	if !autoHeight {
+		imageHeight = config.Height
+		terminalHeight = config.Height - vMargin
+	} else {
+		imageHeight += vMargin + vPadding
+		terminalHeight += vPadding
+	}
+
+	if config.Window {
+		windowControls := svg.NewWindowControls(5.5*float64(scale), 19.0*scale, 12.0*scale)
+		svg.Move(windowControls, float64(config.Margin[left]), float64(config.Margin[top]))
+		image.AddChild(windowControls)
+		config.Padding[top] += (15 * scale)
+	}
+
+	if config.Border.Radius > 0 {
+		svg.AddCornerRadius(terminal, config.Border.Radius*scale)
+	}
+
+	if config.Shadow.Blur > 0 || config.Shadow.X > 0 || config.Shadow.Y > 0 {
+		id := "shadow"
+		svg.AddShadow(image, id, config.Shadow.X*scale, config.Shadow.Y*scale, config.Shadow.Blur*scale)
+		terminal.CreateAttr("filter", fmt.Sprintf("url(#%s)", id))
+	}
+
+	textGroup := image.SelectElement("g")
+	textGroup.CreateAttr("font-size", fmt.Sprintf("%.2fpx", config.Font.Size*float64(scale)))
+	textGroup.CreateAttr("clip-path", "url(#terminalMask)")
+	text := textGroup.SelectElements("text")
+	if pal.Foreground != "" {
+		textGroup.CreateAttr("fill", pal.Foreground)
+	}
+	if pal.Background != "" {
+		terminal.CreateAttr("fill", pal.Background)
+	}
+
+	sw := screenWriter{
+		lines:  text,
+		svg:    textGroup,
+		config: &config,
+		scale:  scale,
+		fg:     textGroup.SelectAttrValue("fill", ""),
+		bg:     terminal.SelectAttrValue("fill", ""),
+	}
+	sw.adapt = newColorAdapter(pal.Adapt, pal.colors(), sw.fg, sw.bg)
+	if cur != nil {
+		sw.cursor = cur
+		sw.cursorColor = cmp.Or(pal.Cursor, sw.fg)
+	}
+
+	offsetLine := 0
+	if len(config.Lines) > 0 {
+		offsetLine = config.Lines[0]
+	}
+
+	config.LineHeight *= float64(scale)
+
+	for i, line := range text {
+		if isAnsi {
+			line.SetText("")
+		}
+		// Offset the text by padding...
+		// (x, y) -> (x+p, y+p)
+		if config.ShowLineNumbers {
+			ln := etree.NewElement("tspan")
+			ln.CreateAttr("xml:space", "preserve")
+			ln.CreateAttr("fill", s.Get(chroma.LineNumbers).Colour.String())
+			ln.SetText(fmt.Sprintf("%3d  ", i+1+offsetLine))
+			line.InsertChildAt(0, ln)
+		}
+		x := float64(config.Padding[left] + config.Margin[left])
+		y := (float64(i+1))*(config.Font.Size*config.LineHeight) + float64(config.Padding[top]) + float64(config.Margin[top])
+
+		svg.Move(line, x, y)
+
+		// We are passed visible lines, remove the rest.
+		if y > float64(imageHeight-config.Margin[bottom]-config.Padding[bottom]) {
+			textGroup.RemoveChild(line)
+		}
+	}
+
+	if autoWidth {
+		tabWidth := 4
+		longestLine := lipgloss.Width(strings.ReplaceAll(strippedInput, "\t", strings.Repeat(" ", tabWidth)))
+		terminalWidth = float64(longestLine+1) * (config.Font.Size / fontHeightToWidthRatio)
+		terminalWidth *= scale
+		terminalWidth += hPadding
+		imageWidth = terminalWidth + hMargin
+	}
+
+	if config.Border.Width > 0 {
+		svg.AddOutline(terminal, config.Border.Width, config.Border.Color)
+
+		// NOTE: necessary so that we don't clip the outline.
+		terminalHeight -= (config.Border.Width * 2)
+		terminalWidth -= (config.Border.Width * 2)
+	}
+
+	if config.ShowLineNumbers {
+		if autoWidth {
+			terminalWidth += config.Font.Size * 3 * scale
+			imageWidth += config.Font.Size * 3 * scale
+		} else {
+			terminalWidth -= config.Font.Size * 3
+		}
+	}
+
+	if !autoHeight || !autoWidth {
+		svg.AddClipPath(image, "terminalMask",
+			config.Margin[left], config.Margin[top],
+			terminalWidth, terminalHeight-config.Padding[bottom])
+	}
+
+	svg.Move(terminal, max(float64(config.Margin[left]), float64(config.Border.Width)/2), max(float64(config.Margin[top]), float64(config.Border.Width)/2))
+	svg.SetDimensions(image, imageWidth, imageHeight)
+	svg.SetDimensions(terminal, terminalWidth, terminalHeight)
+
+	if isAnsi {
+		sw.write(screen)
+	}
+
+	return doc, nil
+}
+
+// detectLexer returns the lexer for the configured language, falling back to
+// the input file name or, when reading from stdin, to the input contents.
+func detectLexer(config Config, input string) chroma.Lexer {
+	if config.Language != "" {
+		return lexers.Get(config.Language)
+	}
+	if config.Input == "" || config.Input == "-" {
+		return lexers.Analyse(input)
+	}
+	return lexers.Get(config.Input)
+}
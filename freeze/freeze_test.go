@@ -0,0 +1,294 @@
+package freeze
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	config := DefaultConfig()
+	config.Language = "go"
+
+	doc, err := Render(context.Background(), config, strings.NewReader("package main\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := doc.Root()
+	if root == nil || root.Tag != "svg" {
+		t.Fatalf("expected svg root element, got %v", root)
+	}
+	if len(root.FindElements("//text")) != 1 {
+		t.Fatal("expected a single line of text")
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		config func(*Config)
+		want   error
+	}{
+		{
+			name:   "unknown language",
+			input:  "artichoke",
+			config: func(c *Config) { c.Input = "artichoke.unknown" },
+			want:   ErrUnknownLanguage,
+		},
+		{
+			name:   "lines out of bounds",
+			input:  "package main\n",
+			config: func(c *Config) { c.Language = "go"; c.Lines = []int{10, 20} },
+			want:   ErrNoInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tc.config(&config)
+			_, err := Render(context.Background(), config, strings.NewReader(tc.input))
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestRenderANSIAttributes(t *testing.T) {
+	config := DefaultConfig()
+	config.Background = "#000000"
+	input := "\x1b[1mbold\x1b[0m \x1b[2mfaint\x1b[0m \x1b[7mreverse\x1b[0m \x1b[31;7mred\x1b[0m \x1b[8mhidden\x1b[0m \x1b[5mblink\x1b[0m\n"
+
+	doc, err := Render(context.Background(), config, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := map[string]map[string]string{}
+	for _, span := range doc.FindElements("//tspan") {
+		attrs := map[string]string{}
+		for _, attr := range span.Attr {
+			attrs[attr.Key] = attr.Value
+		}
+		spans[span.Text()] = attrs
+	}
+	fg := doc.FindElement("//g").SelectAttrValue("fill", "")
+
+	tests := []struct {
+		text, attr, want string
+	}{
+		{"bold", "font-weight", "bold"},
+		{"faint", "fill-opacity", "0.50"},
+		{"reverse", "fill", "#000000"},
+		{"red", "fill", "#000000"},
+		{"hidden", "fill-opacity", "0"},
+	}
+	for _, tc := range tests {
+		if got := spans[tc.text][tc.attr]; got != tc.want {
+			t.Errorf("expected %s of %q to be %q, got %q", tc.attr, tc.text, tc.want, got)
+		}
+	}
+
+	var fills []string
+	for _, rect := range doc.FindElements("//g/rect") {
+		fills = append(fills, rect.SelectAttrValue("fill", ""))
+	}
+	if !slices.Contains(fills, fg) || !slices.Contains(fills, charmColors[1]) {
+		t.Errorf("expected reversed backgrounds of %s and %s, got %v", fg, charmColors[1], fills)
+	}
+	if doc.FindElement("//tspan/animate") == nil {
+		t.Error("expected blinking text to be animated")
+	}
+}
+
+func TestRenderANSIUnderlines(t *testing.T) {
+	config := DefaultConfig()
+	input := "\x1b[4mplain\x1b[0m \x1b[4:3;58;2;255;0;0mcurly\x1b[0m \x1b[21mdouble\x1b[0m \x1b[4:5mdashed\x1b[0m\n"
+
+	doc, err := Render(context.Background(), config, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := map[string]string{}
+	for _, path := range doc.FindElements("//g/path") {
+		paths[path.SelectAttrValue("data-underline", "")] = path.SelectAttrValue("stroke", "")
+	}
+	fg := doc.FindElement("//g").SelectAttrValue("fill", "")
+	expected := map[string]string{"curly": "#ff0000", "double": fg, "dashed": fg}
+	if len(paths) != len(expected) {
+		t.Errorf("expected underlines %v, got %v", expected, paths)
+	}
+	for style, color := range expected {
+		if paths[style] != color {
+			t.Errorf("expected a %s underline in %s, got %q", style, color, paths[style])
+		}
+	}
+
+	s := parseScene(doc)
+	if len(s.underlines) != len(expected) {
+		t.Fatalf("expected %d underlines in the scene, got %d", len(expected), len(s.underlines))
+	}
+	for _, u := range s.underlines {
+		if u.x2 <= u.x1 || len(u.polygons()) == 0 {
+			t.Errorf("expected the %s underline to cover the text, got %+v", u.style, u)
+		}
+	}
+}
+
+func TestRenderANSILinks(t *testing.T) {
+	config := DefaultConfig()
+	config.UnderlineLinks = true
+	input := "see \x1b]8;;https://charm.sh\x1b\\charm.sh\x1b]8;;\x1b\\ docs\n"
+
+	doc, err := Render(context.Background(), config, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	link := doc.FindElement("//text/a")
+	if link == nil {
+		t.Fatal("expected a link")
+	}
+	if href := link.SelectAttrValue("href", ""); href != "https://charm.sh" {
+		t.Errorf("expected a link to https://charm.sh, got %q", href)
+	}
+	span := link.SelectElement("tspan")
+	if span == nil || span.Text() != "charm.sh" {
+		t.Fatalf("expected the link to wrap charm.sh, got %v", span)
+	}
+	if decoration := span.SelectAttrValue("text-decoration", ""); decoration != "underline" {
+		t.Errorf("expected the link to be underlined, got %q", decoration)
+	}
+
+	s := parseScene(doc)
+	var links []string
+	for _, span := range s.lines[0].spans {
+		links = append(links, span.link)
+	}
+	if !slices.Equal(links, []string{"", "https://charm.sh", ""}) {
+		t.Errorf("expected the scene to keep the link, got %q", links)
+	}
+}
+
+func TestRenderANSICursorPastLine(t *testing.T) {
+	config := DefaultConfig()
+	config.Cursor = "block"
+	config.Language = "ansi"
+	doc, err := Render(context.Background(), config, strings.NewReader("$ ls\x1b[1;40H"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rect := doc.FindElement("//rect[@data-cursor]")
+	if rect == nil {
+		t.Fatal("expected a cursor")
+	}
+	x, y := parseLength(rect.SelectAttrValue("x", ""), 0), parseLength(rect.SelectAttrValue("y", ""), 0)
+	width, height := parseLength(rect.SelectAttrValue("width", ""), 0), parseLength(rect.SelectAttrValue("height", ""), 0)
+	s := parseScene(doc)
+	if x+width > s.terminal.x+s.terminal.width-config.Padding[right] || y+height > s.terminal.y+s.terminal.height {
+		t.Errorf("expected the cursor at %.2f,%.2f inside the %.2fx%.2f window", x+width, y+height, s.terminal.width, s.terminal.height)
+	}
+}
+
+func TestRenderANSILinkSchemes(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{"https://charm.sh", "https://charm.sh"},
+		{"HTTP://charm.sh", "HTTP://charm.sh"},
+		{"mailto:vt100@charm.sh", "mailto:vt100@charm.sh"},
+		{"file:///etc/hosts", "file:///etc/hosts"},
+		{"javascript:alert(1)", ""},
+		{"data:text/html,<script>alert(1)</script>", ""},
+		{"charm.sh", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.uri, func(t *testing.T) {
+			config := DefaultConfig()
+			input := "see \x1b]8;;" + tc.uri + "\x1b\\charm\x1b]8;;\x1b\\ docs\n"
+			doc, err := Render(context.Background(), config, strings.NewReader(input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var href string
+			if link := doc.FindElement("//text/a"); link != nil {
+				href = link.SelectAttrValue("href", "")
+			}
+			if href != tc.want {
+				t.Errorf("expected a link to %q, got %q", tc.want, href)
+			}
+			var text strings.Builder
+			for _, span := range parseScene(doc).lines[0].spans {
+				text.WriteString(span.text)
+			}
+			if text.String() != "see charm docs" {
+				t.Errorf("expected the text of the link to be kept, got %q", text.String())
+			}
+		})
+	}
+}
+
+func TestRenderANSICursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+		input  string
+		want   string
+	}{
+		{"none", "none", "$ ", ""},
+		{"block", "block", "$ ", "block"},
+		{"shape of the output", "auto", "$ \x1b[5 q", "bar"},
+		{"option over output", "underline", "$ \x1b[5 q", "underline"},
+		{"hidden", "block", "$ \x1b[?25l", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Cursor = tc.cursor
+			config.Language = "ansi"
+			doc, err := Render(context.Background(), config, strings.NewReader(tc.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rect := doc.FindElement("//rect[@data-cursor]")
+			if tc.want == "" {
+				if rect != nil {
+					t.Errorf("expected no cursor, got %s", rect.SelectAttrValue("data-cursor", ""))
+				}
+				return
+			}
+			if rect == nil {
+				t.Fatal("expected a cursor")
+			}
+			if shape := rect.SelectAttrValue("data-cursor", ""); shape != tc.want {
+				t.Errorf("expected a %s cursor, got %s", tc.want, shape)
+			}
+			if fill := rect.SelectAttrValue("fill", ""); fill != palettes["charm"].Cursor {
+				t.Errorf("expected the cursor color of the palette, got %s", fill)
+			}
+		})
+	}
+}
+
+func TestWithCursorCell(t *testing.T) {
+	rows := emulate("a世", 0, 0, charmColors)
+
+	got, c := withCursorCell(rows, cursor{x: 5})
+	if len(got[0]) != 6 || c.x != 5 {
+		t.Errorf("expected blank cells up to the cursor, got %d cells and the cursor at %d", len(got[0]), c.x)
+	}
+	if _, c = withCursorCell(rows, cursor{x: 2}); c.x != 1 {
+		t.Errorf("expected the cursor at the start of the wide character, got %d", c.x)
+	}
+}
@@ -0,0 +1,77 @@
+package freeze
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"time"
+)
+
+// gifEncoder encodes an animated GIF. Every frame is reduced to its own
+// palette of 256 colors.
+type gifEncoder struct {
+	g    gif.GIF
+	prev *image.NRGBA
+}
+
+func (e *gifEncoder) addFrame(img *image.NRGBA, changed image.Rectangle, delay time.Duration) error {
+	if len(e.g.Image) == 0 {
+		e.g.Config.Width = img.Bounds().Dx()
+		e.g.Config.Height = img.Bounds().Dy()
+	}
+
+	if n := len(e.g.Image); n > 0 && hasTransparency(img, changed) {
+		// transparent pixels show what is under them, so clear the previous
+		// frame, drawn again over the changed area too, and draw all of it.
+		changed = changed.Union(e.g.Image[n-1].Rect)
+		e.g.Image[n-1] = quantizeRect(e.prev, changed)
+		e.g.Disposal[n-1] = gif.DisposalBackground
+	}
+
+	e.g.Image = append(e.g.Image, quantizeRect(img, changed))
+	e.prev = img
+	// GIF delays are in hundredths of a second, and most viewers slow down
+	// anything faster than 2.
+	e.g.Delay = append(e.g.Delay, max(int(delay/(10*time.Millisecond)), 2))
+	e.g.Disposal = append(e.g.Disposal, gif.DisposalNone)
+	return nil
+}
+
+// quantizeRect reduces the part of the image within r to 256 colors.
+func quantizeRect(img *image.NRGBA, r image.Rectangle) *image.Paletted {
+	frame := quantize(img.SubImage(r), 256)
+	frame.Rect = frame.Rect.Add(r.Min)
+	return frame
+}
+
+// hasTransparency reports whether any pixel of the image within r is not
+// fully opaque.
+func hasTransparency(img *image.NRGBA, r image.Rectangle) bool {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		i := img.PixOffset(r.Min.X, y)
+		for x := r.Min.X; x < r.Max.X; x, i = x+1, i+4 {
+			if img.Pix[i+3] != 0xff {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e *gifEncoder) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, &e.g); err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeGIF encodes the image as a single frame GIF.
+func encodeGIF(img image.Image) ([]byte, error) {
+	nrgba := toNRGBA(img)
+	var e gifEncoder
+	if err := e.addFrame(nrgba, nrgba.Bounds(), 0); err != nil {
+		return nil, err
+	}
+	return e.encode()
+}
@@ -0,0 +1,223 @@
+package freeze
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/charmbracelet/freeze/font"
+)
+
+// The vertical metrics of JetBrains Mono, relative to the font size. They
+// place the baseline of HTML text where the SVG puts it.
+const (
+	fontAscent  = 1.02
+	fontDescent = 0.3
+)
+
+// encodeHTML draws the rendered document as a standalone HTML page. The
+// window is drawn with CSS and the code is written as text, so it can be
+// selected and copied.
+func encodeHTML(config Config, doc *etree.Document) ([]byte, error) {
+	s := parseScene(doc)
+	if s.width <= 0 || s.height <= 0 {
+		return nil, fmt.Errorf("invalid dimensions %.2fx%.2f", s.width, s.height)
+	}
+
+	fontFace, err := htmlFontFace(config)
+	if err != nil {
+		return nil, err
+	}
+
+	title := "freeze"
+	if config.Input != "" && config.Input != "-" {
+		title = filepath.Base(config.Input)
+	}
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n<style>\n", html.EscapeString(title))
+	b.WriteString(fontFace)
+	b.WriteString("body { margin: 0; }\n")
+	fmt.Fprintf(&b, ".freeze { position: relative; width: %s; height: %s; }\n", px(s.width), px(s.height))
+	b.WriteString(".freeze div { position: absolute; box-sizing: border-box; }\n")
+	fmt.Fprintf(&b, ".freeze pre { position: absolute; margin: 0; white-space: pre; font-family: %s, monospace; font-size: %s; color: %s; }\n",
+		cssString(config.Font.Family), px(s.fontSize), html.EscapeString(s.fill))
+	b.WriteString(".freeze .ln { user-select: none; -webkit-user-select: none; }\n")
+	b.WriteString(".freeze a { color: inherit; text-decoration: none; }\n")
+	b.WriteString("</style>\n</head>\n<body>\n<div class=\"freeze\">\n")
+
+	// the stroke is centered on the edge of the terminal, while CSS borders
+	// are drawn inside of the box.
+	t := s.terminal
+	sw := t.strokeWidth
+	style := []string{
+		"left: " + px(t.x-sw/2), "top: " + px(t.y-sw/2),
+		"width: " + px(t.width+sw), "height: " + px(t.height+sw),
+		"border-radius: " + px(t.radius+sw/2),
+		"background: " + html.EscapeString(t.fill),
+	}
+	if sw > 0 && t.stroke != "" {
+		style = append(style, fmt.Sprintf("border: %s solid %s", px(sw), html.EscapeString(t.stroke)))
+	}
+	if s.shadow != nil {
+		// CSS blur radii are twice the standard deviation of the SVG blur.
+		style = append(style, fmt.Sprintf("box-shadow: %s %s %s #000", px(s.shadow.x), px(s.shadow.y), px(s.shadow.blur*2)))
+	}
+	fmt.Fprintf(&b, "<div style=\"%s\"></div>\n", strings.Join(style, "; "))
+
+	for _, c := range s.circles {
+		fmt.Fprintf(&b, "<div style=\"left: %s; top: %s; width: %s; height: %s; border-radius: 50%%; background: %s\"></div>\n",
+			px(c.x-c.radius), px(c.y-c.radius), px(c.radius*2), px(c.radius*2), html.EscapeString(c.fill))
+	}
+
+	clip := sceneRect{width: s.width, height: s.height}
+	if s.clip != nil {
+		clip = *s.clip
+	}
+	fmt.Fprintf(&b, "<div style=\"left: %s; top: %s; width: %s; height: %s; overflow: hidden\">\n",
+		px(clip.x), px(clip.y), px(clip.width), px(clip.height))
+
+	for _, bg := range s.backgrounds {
+		fmt.Fprintf(&b, "<div style=\"left: %s; top: %s; width: %s; height: %s; background: %s\"></div>\n",
+			px(bg.x-clip.x), px(bg.y-clip.y), px(bg.width), px(bg.height), html.EscapeString(bg.fill))
+	}
+
+	if len(s.lines) > 0 {
+		lineHeight := s.fontSize * config.LineHeight
+		if len(s.lines) > 1 {
+			lineHeight = s.lines[1].y - s.lines[0].y
+		}
+		first := s.lines[0]
+		// move the first baseline to where the SVG draws it.
+		top := first.y - (lineHeight-(fontAscent+fontDescent)*s.fontSize)/2 - fontAscent*s.fontSize
+		fmt.Fprintf(&b, "<pre style=\"left: %s; top: %s; line-height: %s\">",
+			px(first.x-clip.x), px(top-clip.y), px(lineHeight))
+		for i, line := range s.lines {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+			var link string
+			for j, span := range line.spans {
+				if span.link != link {
+					if link != "" {
+						b.WriteString("</a>")
+					}
+					if span.link != "" {
+						fmt.Fprintf(&b, "<a href=\"%s\">", html.EscapeString(span.link))
+					}
+					link = span.link
+				}
+				writeHTMLSpan(&b, span, config.ShowLineNumbers && j == 0)
+			}
+			if link != "" {
+				b.WriteString("</a>")
+			}
+		}
+		b.WriteString("</pre>\n")
+	}
+
+	if len(s.underlines) > 0 {
+		// CSS cannot draw every underline style, so draw them as in the SVG.
+		fmt.Fprintf(&b, "<svg style=\"position: absolute; left: 0; top: 0\" width=\"%.2f\" height=\"%.2f\" viewBox=\"%.2f %.2f %.2f %.2f\">\n",
+			clip.width, clip.height, clip.x, clip.y, clip.width, clip.height)
+		for _, u := range s.underlines {
+			fmt.Fprintf(&b, "<path d=\"%s\" fill=\"none\" stroke=\"%s\" stroke-width=\"%.2f\"", u.d, html.EscapeString(u.color), u.thickness)
+			if u.dasharray != "" {
+				fmt.Fprintf(&b, " stroke-dasharray=\"%s\"", u.dasharray)
+			}
+			b.WriteString("/>\n")
+		}
+		b.WriteString("</svg>\n")
+	}
+
+	b.WriteString("</div>\n</div>\n</body>\n</html>\n")
+	return []byte(b.String()), nil
+}
+
+func writeHTMLSpan(b *strings.Builder, span sceneSpan, lineNumber bool) {
+	var style []string
+	if span.dx != 0 {
+		style = append(style, "margin-left: "+px(span.dx))
+	}
+	if span.fill != "" {
+		style = append(style, "color: "+html.EscapeString(span.fill))
+	}
+	if span.opacity != 1 {
+		style = append(style, fmt.Sprintf("opacity: %.2f", span.opacity))
+	}
+	if span.bold {
+		style = append(style, "font-weight: bold")
+	}
+	if span.italic {
+		style = append(style, "font-style: italic")
+	}
+	var decorations []string
+	if span.underline {
+		decorations = append(decorations, "underline")
+	}
+	if span.strike {
+		decorations = append(decorations, "line-through")
+	}
+	if len(decorations) > 0 {
+		style = append(style, "text-decoration: "+strings.Join(decorations, " "))
+	}
+
+	b.WriteString("<span")
+	if lineNumber {
+		b.WriteString(` class="ln"`)
+	}
+	if len(style) > 0 {
+		fmt.Fprintf(b, " style=\"%s\"", strings.Join(style, "; "))
+	}
+	b.WriteString(">")
+	b.WriteString(html.EscapeString(span.text))
+	b.WriteString("</span>")
+}
+
+// htmlFontFace returns the CSS font face embedding the font, if freeze has
+// it: either the font file or the bundled JetBrains Mono.
+func htmlFontFace(config Config) (string, error) {
+	var data []byte
+	format := "truetype"
+	mime := "font/ttf"
+	switch {
+	case config.Font.File != "":
+		b, err := os.ReadFile(config.Font.File)
+		if err != nil {
+			return "", fmt.Errorf("invalid font file: %w", err)
+		}
+		data = b
+		switch ext := strings.ToLower(filepath.Ext(config.Font.File)); ext {
+		case ".ttf":
+		case ".woff2":
+			format, mime = "woff2", "font/woff2"
+		case ".woff":
+			format, mime = "woff", "font/woff"
+		default:
+			return "", fmt.Errorf("%s is not a supported font extension", ext)
+		}
+	case config.Font.Family == "JetBrains Mono":
+		data = font.JetBrainsMonoTTF
+		if !config.Font.Ligatures {
+			data = font.JetBrainsMonoNLTTF
+		}
+	default:
+		return "", nil
+	}
+	return fmt.Sprintf("@font-face { font-family: %s; src: url(data:%s;base64,%s) format(%q); }\n",
+		cssString(config.Font.Family), mime, base64.StdEncoding.EncodeToString(data), format), nil
+}
+
+// cssString quotes s as a CSS string.
+func cssString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "<", `\3c `).Replace(s) + `"`
+}
+
+func px(v float64) string {
+	return fmt.Sprintf("%.2fpx", v)
+}
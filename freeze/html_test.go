@@ -0,0 +1,65 @@
+package freeze
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestEncodeHTML(t *testing.T) {
+	config := DefaultConfig()
+	config.Language = "go"
+	config.Window = true
+	config.ShowLineNumbers = true
+	config.Output = "main.html"
+	config.Shadow = Shadow{Blur: 10, Y: 5}
+	config.Border = Border{Radius: 8, Width: 1, Color: "#515151"}
+
+	doc, err := Render(context.Background(), config, strings.NewReader("if a < b && c > d {\n\tfmt.Println(\"hi\")\n}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Encode(context.Background(), config, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		`@font-face { font-family: "JetBrains Mono"; src: url(data:font/ttf;base64,`,
+		"box-shadow: 0.00px 5.00px 20.00px #000",
+		"border: 1.00px solid #515151",
+		"border-radius: 50%",
+		`<span class="ln"`,
+		"&lt;",
+		"&amp;&amp;",
+		"&#34;hi&#34;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q", want)
+		}
+	}
+	if strings.Contains(out, "<image") || strings.Contains(out, "<svg") {
+		t.Error("expected the code to be written as text")
+	}
+}
+
+func TestEncodeHTMLEscapesColors(t *testing.T) {
+	config := DefaultConfig()
+	config.Language = "go"
+	config.Output = "main.html"
+	config.Border = Border{Width: 1, Color: `red" onmouseover="alert(1)`}
+
+	doc, err := Render(context.Background(), config, strings.NewReader("package main\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Encode(context.Background(), config, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := string(b); strings.Contains(out, `" onmouseover="`) {
+		t.Error("expected the border color to be escaped")
+	}
+}
@@ -0,0 +1,89 @@
+package freeze
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// defaultQuality is the quality of JPEG and AVIF images without one.
+const defaultQuality = 90
+
+// encodeJPEG encodes the image as a JPEG. JPEG has no alpha channel, so the
+// transparent margins are flattened onto the background color.
+func encodeJPEG(img image.Image, quality int, background string) ([]byte, error) {
+	if quality <= 0 {
+		quality = defaultQuality
+	}
+
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, flatten(img, background), &jpeg.Options{Quality: clamp(quality, 1, 100)})
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+	return buf.Bytes(), nil
+}
+
+// flatten draws the image over an opaque background.
+func flatten(img image.Image, background string) image.Image {
+	bg, err := parseHexColor(background)
+	if err != nil {
+		bg = color.NRGBA{A: 0xff}
+	}
+	bg.A = 0xff
+
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
+
+// encodeAVIF encodes the image as an AVIF with avifenc from libavif, which
+// has to be installed.
+func encodeAVIF(ctx context.Context, img image.Image, quality int, lossless bool) ([]byte, error) {
+	if _, err := exec.LookPath("avifenc"); err != nil {
+		return nil, errors.New("avif output requires avifenc (libavif) to be installed")
+	}
+	if quality <= 0 {
+		quality = defaultQuality
+	}
+
+	dir, err := os.MkdirTemp("", "freeze")
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+	defer os.RemoveAll(dir) //nolint: errcheck
+
+	input := filepath.Join(dir, "freeze.png")
+	output := filepath.Join(dir, "freeze.avif")
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+	if err := os.WriteFile(input, buf.Bytes(), 0o600); err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+
+	args := []string{"--qcolor", strconv.Itoa(clamp(quality, 1, 100)), "--qalpha", "100"}
+	if lossless {
+		args = []string{"--lossless"}
+	}
+	args = append(args, input, output)
+
+	out, err := exec.CommandContext(ctx, "avifenc", args...).CombinedOutput() //nolint: gosec
+	if err != nil {
+		return nil, fmt.Errorf("avifenc: %w\n%s", err, out)
+	}
+	return os.ReadFile(output) //nolint: wrapcheck
+}
@@ -0,0 +1,20 @@
+package freeze
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(1, 0, color.NRGBA{R: 0xff, A: 0xff})
+
+	got := flatten(img, "#171717")
+	if r, g, b, a := got.At(0, 0).RGBA(); r>>8 != 0x17 || g>>8 != 0x17 || b>>8 != 0x17 || a>>8 != 0xff {
+		t.Fatalf("expected transparent pixel to be flattened onto background, got %v", got.At(0, 0))
+	}
+	if r, _, _, _ := got.At(1, 0).RGBA(); r>>8 != 0xff {
+		t.Fatalf("expected opaque pixel to be kept, got %v", got.At(1, 0))
+	}
+}
@@ -0,0 +1,195 @@
+package freeze
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Palette is the configuration options for the colors of terminal output.
+type Palette struct {
+	Name       string   `json:"name,omitempty" help:"Built-in palette for terminal output. Defaults to the palette of the theme." placeholder:"dracula"`
+	File       string   `json:"file,omitempty" help:"Import the palette from an Alacritty, kitty, Windows Terminal or iTerm2 config." placeholder:"alacritty.toml"`
+	Colors     []string `json:"colors,omitempty" help:"The 16 ANSI colors of terminal output." placeholder:"#000000,#ff0000"`
+	Foreground string   `json:"foreground,omitempty" help:"Default text color of terminal output." placeholder:"#c4c4c4"`
+	Background string   `json:"background,omitempty" help:"Default background color of terminal output." placeholder:"#171717"`
+	Cursor     string   `json:"cursor,omitempty" help:"Cursor color of terminal output." placeholder:"#ffffff"`
+	Adapt      string   `json:"adapt,omitempty" help:"Adapt the colors of terminal output to the theme: none, contrast or palette." enum:",none,contrast,palette" default:"none" placeholder:"contrast"`
+}
+
+// terminalColors are the 256 colors of a terminal: the 16 ANSI colors,
+// followed by a 6x6x6 color cube and a grayscale ramp.
+type terminalColors [256]string
+
+// palettes are the built-in palettes, named after the themes they go with.
+// They leave the default colors to the theme.
+var palettes = map[string]Palette{
+	"charm": {Colors: []string{
+		"#282a2e", "#D74E6F", "#31BB71", "#D3E561", "#8056FF", "#ED61D7", "#04D7D7", "#C5C8C6",
+		"#4B4B4B", "#FE5F86", "#00D787", "#EBFF71", "#8F69FF", "#FF7AEA", "#00FEFE", "#FFFFFF",
+	}, Cursor: "#FFFFFF"},
+	"catppuccin-latte": {Colors: []string{
+		"#5c5f77", "#d20f39", "#40a02b", "#df8e1d", "#1e66f5", "#ea76cb", "#179299", "#acb0be",
+		"#6c6f85", "#d20f39", "#40a02b", "#df8e1d", "#1e66f5", "#ea76cb", "#179299", "#bcc0cc",
+	}, Cursor: "#dc8a78"},
+	"catppuccin-mocha": {Colors: []string{
+		"#45475a", "#f38ba8", "#a6e3a1", "#f9e2af", "#89b4fa", "#f5c2e7", "#94e2d5", "#bac2de",
+		"#585b70", "#f38ba8", "#a6e3a1", "#f9e2af", "#89b4fa", "#f5c2e7", "#94e2d5", "#a6adc8",
+	}, Cursor: "#f5e0dc"},
+	"dracula": {Colors: []string{
+		"#21222c", "#ff5555", "#50fa7b", "#f1fa8c", "#bd93f9", "#ff79c6", "#8be9fd", "#f8f8f2",
+		"#6272a4", "#ff6e6e", "#69ff94", "#ffffa5", "#d6acff", "#ff92df", "#a4ffff", "#ffffff",
+	}, Cursor: "#f8f8f2"},
+	"github": {Colors: []string{
+		"#24292e", "#d73a49", "#28a745", "#dbab09", "#0366d6", "#5a32a3", "#1b7c83", "#6a737d",
+		"#959da5", "#cb2431", "#22863a", "#b08800", "#005cc5", "#5a32a3", "#3192aa", "#d1d5da",
+	}, Cursor: "#044289"},
+	"github-dark": {Colors: []string{
+		"#484f58", "#ff7b72", "#3fb950", "#d29922", "#58a6ff", "#bc8cff", "#39c5cf", "#b1bac4",
+		"#6e7681", "#ffa198", "#56d364", "#e3b341", "#79c0ff", "#d2a8ff", "#56d4dd", "#f0f6fc",
+	}, Cursor: "#58a6ff"},
+	"gruvbox": {Colors: []string{
+		"#282828", "#cc241d", "#98971a", "#d79921", "#458588", "#b16286", "#689d6a", "#a89984",
+		"#928374", "#fb4934", "#b8bb26", "#fabd2f", "#83a598", "#d3869b", "#8ec07c", "#ebdbb2",
+	}, Cursor: "#ebdbb2"},
+	"gruvbox-light": {Colors: []string{
+		"#fbf1c7", "#cc241d", "#98971a", "#d79921", "#458588", "#b16286", "#689d6a", "#7c6f64",
+		"#928374", "#9d0006", "#79740e", "#b57614", "#076678", "#8f3f71", "#427b58", "#3c3836",
+	}, Cursor: "#3c3836"},
+	"monokai": {Colors: []string{
+		"#272822", "#f92672", "#a6e22e", "#f4bf75", "#66d9ef", "#ae81ff", "#a1efe4", "#f8f8f2",
+		"#75715e", "#f92672", "#a6e22e", "#f4bf75", "#66d9ef", "#ae81ff", "#a1efe4", "#f9f8f5",
+	}, Cursor: "#f8f8f0"},
+	"nord": {Colors: []string{
+		"#3b4252", "#bf616a", "#a3be8c", "#ebcb8b", "#81a1c1", "#b48ead", "#88c0d0", "#e5e9f0",
+		"#4c566a", "#bf616a", "#a3be8c", "#ebcb8b", "#81a1c1", "#b48ead", "#8fbcbb", "#eceff4",
+	}, Cursor: "#d8dee9"},
+	"onedark": {Colors: []string{
+		"#282c34", "#e06c75", "#98c379", "#e5c07b", "#61afef", "#c678dd", "#56b6c2", "#abb2bf",
+		"#5c6370", "#e06c75", "#98c379", "#e5c07b", "#61afef", "#c678dd", "#56b6c2", "#ffffff",
+	}, Cursor: "#528bff"},
+	"rose-pine": {Colors: []string{
+		"#26233a", "#eb6f92", "#31748f", "#f6c177", "#9ccfd8", "#c4a7e7", "#ebbcba", "#e0def4",
+		"#6e6a86", "#eb6f92", "#31748f", "#f6c177", "#9ccfd8", "#c4a7e7", "#ebbcba", "#e0def4",
+	}, Cursor: "#524f67"},
+	"solarized-dark": {Colors: []string{
+		"#073642", "#dc322f", "#859900", "#b58900", "#268bd2", "#d33682", "#2aa198", "#eee8d5",
+		"#002b36", "#cb4b16", "#586e75", "#657b83", "#839496", "#6c71c4", "#93a1a1", "#fdf6e3",
+	}, Cursor: "#839496"},
+	"solarized-light": {Colors: []string{
+		"#073642", "#dc322f", "#859900", "#b58900", "#268bd2", "#d33682", "#2aa198", "#eee8d5",
+		"#002b36", "#cb4b16", "#586e75", "#657b83", "#839496", "#6c71c4", "#93a1a1", "#fdf6e3",
+	}, Cursor: "#657b83"},
+	"tokyonight-night": {Colors: []string{
+		"#15161e", "#f7768e", "#9ece6a", "#e0af68", "#7aa2f7", "#bb9af7", "#7dcfff", "#a9b1d6",
+		"#414868", "#f7768e", "#9ece6a", "#e0af68", "#7aa2f7", "#bb9af7", "#7dcfff", "#c0caf5",
+	}, Cursor: "#c0caf5"},
+}
+
+// terminalPalette returns the palette of terminal output: the palette of
+// the theme, or the built-in palette it names, with the colors of the
+// imported file and the configured colors on top.
+func terminalPalette(config Config) (Palette, error) {
+	p, ok := palettes[strings.ToLower(config.Theme)]
+	if !ok {
+		p = palettes["charm"]
+	}
+	if name := config.Palette.Name; name != "" {
+		p, ok = palettes[strings.ToLower(name)]
+		if !ok {
+			return p, fmt.Errorf("unknown palette %q", name)
+		}
+	}
+	if config.Palette.File != "" {
+		imported, err := importPalette(config.Palette.File)
+		if err != nil {
+			return p, fmt.Errorf("invalid palette file: %w", err)
+		}
+		p = p.merge(imported)
+	}
+	p = p.merge(config.Palette)
+
+	switch p.Adapt {
+	case "", "none", "contrast", "palette":
+	default:
+		return p, fmt.Errorf("unknown color adaptation %q, use one of none, contrast or palette", p.Adapt)
+	}
+	if len(p.Colors) > 16 {
+		return p, fmt.Errorf("a palette has 16 colors, got %d", len(p.Colors))
+	}
+	for _, c := range slices.Concat(p.Colors, []string{p.Foreground, p.Background, p.Cursor}) {
+		if c == "" {
+			continue
+		}
+		if _, err := parseHexColor(c); err != nil {
+			return p, fmt.Errorf("invalid palette: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// merge returns the palette with the colors set in other replacing its own.
+// Colors left empty in other are kept.
+func (p Palette) merge(other Palette) Palette {
+	colors := make([]string, max(len(p.Colors), len(other.Colors)))
+	copy(colors, p.Colors)
+	for i, c := range other.Colors {
+		if c != "" {
+			colors[i] = c
+		}
+	}
+	p.Colors = colors
+	if other.Foreground != "" {
+		p.Foreground = other.Foreground
+	}
+	if other.Background != "" {
+		p.Background = other.Background
+	}
+	if other.Cursor != "" {
+		p.Cursor = other.Cursor
+	}
+	if other.Adapt != "" {
+		p.Adapt = other.Adapt
+	}
+	return p
+}
+
+// colors returns the 256 colors of a terminal with the palette. The color
+// cube and the grayscale ramp are the same in every palette.
+func (p Palette) colors() terminalColors {
+	var colors terminalColors
+	copy(colors[:], palettes["charm"].Colors)
+	for i, c := range p.Colors {
+		if c != "" {
+			colors[i] = c
+		}
+	}
+
+	levels := [6]int{0, 95, 135, 175, 215, 255}
+	for i := range 216 {
+		colors[16+i] = fmt.Sprintf("#%02x%02x%02x", levels[i/36], levels[i/6%6], levels[i%6])
+	}
+	for i := range 24 {
+		v := 8 + i*10
+		colors[232+i] = fmt.Sprintf("#%02x%02x%02x", v, v, v)
+	}
+	return colors
+}
+
+// importPalette reads the palette of a terminal config, picking the format
+// from the file name.
+func importPalette(path string) (Palette, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		return importAlacritty(path)
+	case ".conf":
+		return importKitty(path)
+	case ".json":
+		return importWindowsTerminal(path)
+	case ".itermcolors":
+		return importITerm(path)
+	default:
+		return Palette{}, fmt.Errorf("%s is not a supported palette file, use an Alacritty .toml, kitty .conf, Windows Terminal .json or iTerm2 .itermcolors file", path)
+	}
+}
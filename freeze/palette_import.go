@@ -0,0 +1,316 @@
+package freeze
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ansiColorNames are the names terminal configs give the 8 ANSI colors, in
+// order.
+var ansiColorNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+var errNoColors = errors.New("no colors found")
+
+// importAlacritty reads the colors of an Alacritty TOML config: the
+// [colors.primary], [colors.normal], [colors.bright] and [colors.cursor]
+// tables. Only the string values of those tables are read, so a full TOML
+// parser isn't needed.
+func importAlacritty(path string) (Palette, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Palette{}, err //nolint: wrapcheck
+	}
+	defer f.Close() //nolint: errcheck
+
+	var p Palette
+	var table string
+	found := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if strings.HasPrefix(line, "[") {
+			table = strings.TrimSpace(strings.Trim(line, "[]"))
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if strings.HasPrefix(value, "0x") {
+			value = "#" + value[2:]
+		}
+
+		switch table {
+		case "colors.primary":
+			switch key {
+			case "foreground":
+				p.Foreground, found = value, true
+			case "background":
+				p.Background, found = value, true
+			}
+		case "colors.cursor":
+			if key == "cursor" {
+				p.Cursor, found = value, true
+			}
+		case "colors.normal", "colors.bright":
+			i := slices.Index(ansiColorNames, key)
+			if i < 0 {
+				continue
+			}
+			if table == "colors.bright" {
+				i += 8
+			}
+			p.setColor(i, value)
+			found = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Palette{}, err //nolint: wrapcheck
+	}
+	if !found {
+		return Palette{}, errNoColors
+	}
+	return p, nil
+}
+
+// importKitty reads the colors of a kitty.conf, set with color0 to color15,
+// foreground, background and cursor.
+func importKitty(path string) (Palette, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Palette{}, err //nolint: wrapcheck
+	}
+	defer f.Close() //nolint: errcheck
+
+	var p Palette
+	found := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		key, value := fields[0], fields[1]
+		switch key {
+		case "foreground":
+			p.Foreground, found = value, true
+		case "background":
+			p.Background, found = value, true
+		case "cursor":
+			if value != "none" {
+				p.Cursor, found = value, true
+			}
+		default:
+			i, err := strconv.Atoi(strings.TrimPrefix(key, "color"))
+			if !strings.HasPrefix(key, "color") || err != nil || i < 0 || i >= 16 {
+				continue
+			}
+			p.setColor(i, value)
+			found = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Palette{}, err //nolint: wrapcheck
+	}
+	if !found {
+		return Palette{}, errNoColors
+	}
+	return p, nil
+}
+
+// windowsTerminalScheme is a color scheme of Windows Terminal.
+type windowsTerminalScheme struct {
+	Name         string `json:"name"`
+	Foreground   string `json:"foreground"`
+	Background   string `json:"background"`
+	CursorColor  string `json:"cursorColor"`
+	Black        string `json:"black"`
+	Red          string `json:"red"`
+	Green        string `json:"green"`
+	Yellow       string `json:"yellow"`
+	Blue         string `json:"blue"`
+	Purple       string `json:"purple"`
+	Cyan         string `json:"cyan"`
+	White        string `json:"white"`
+	BrightBlack  string `json:"brightBlack"`
+	BrightRed    string `json:"brightRed"`
+	BrightGreen  string `json:"brightGreen"`
+	BrightYellow string `json:"brightYellow"`
+	BrightBlue   string `json:"brightBlue"`
+	BrightPurple string `json:"brightPurple"`
+	BrightCyan   string `json:"brightCyan"`
+	BrightWhite  string `json:"brightWhite"`
+}
+
+// importWindowsTerminal reads the colors of a Windows Terminal color scheme,
+// either on its own or as the first of the schemes of a settings.json.
+func importWindowsTerminal(path string) (Palette, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Palette{}, err //nolint: wrapcheck
+	}
+
+	var settings struct {
+		Schemes []windowsTerminalScheme `json:"schemes"`
+	}
+	if err := json.Unmarshal(b, &settings); err != nil {
+		return Palette{}, err //nolint: wrapcheck
+	}
+	var scheme windowsTerminalScheme
+	if len(settings.Schemes) > 0 {
+		scheme = settings.Schemes[0]
+	} else if err := json.Unmarshal(b, &scheme); err != nil {
+		return Palette{}, err //nolint: wrapcheck
+	}
+
+	p := Palette{
+		Colors: []string{
+			scheme.Black, scheme.Red, scheme.Green, scheme.Yellow,
+			scheme.Blue, scheme.Purple, scheme.Cyan, scheme.White,
+			scheme.BrightBlack, scheme.BrightRed, scheme.BrightGreen, scheme.BrightYellow,
+			scheme.BrightBlue, scheme.BrightPurple, scheme.BrightCyan, scheme.BrightWhite,
+		},
+		Foreground: scheme.Foreground,
+		Background: scheme.Background,
+		Cursor:     scheme.CursorColor,
+	}
+	if !slices.ContainsFunc(slices.Concat(p.Colors, []string{p.Foreground, p.Background, p.Cursor}), isSet) {
+		return Palette{}, errNoColors
+	}
+	return p, nil
+}
+
+// importITerm reads the colors of an iTerm2 .itermcolors file, a property
+// list of color dictionaries with their components from 0 to 1.
+func importITerm(path string) (Palette, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Palette{}, err //nolint: wrapcheck
+	}
+	defer f.Close() //nolint: errcheck
+
+	colors := map[string]*[3]float64{}
+	var key, component string
+	var current *[3]float64
+	var text strings.Builder
+	depth := 0
+
+	decoder := xml.NewDecoder(f)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Palette{}, err //nolint: wrapcheck
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			text.Reset()
+			if token.Name.Local == "dict" {
+				depth++
+				if depth == 2 {
+					current = &[3]float64{}
+					colors[key] = current
+				}
+			}
+		case xml.CharData:
+			text.Write(token)
+		case xml.EndElement:
+			value := strings.TrimSpace(text.String())
+			switch token.Name.Local {
+			case "dict":
+				depth--
+			case "key":
+				if depth == 1 {
+					key = value
+				} else {
+					component = value
+				}
+			case "real", "integer":
+				if depth != 2 {
+					continue
+				}
+				v, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return Palette{}, fmt.Errorf("invalid %s of %s: %w", component, key, err)
+				}
+				switch component {
+				case "Red Component":
+					current[0] = v
+				case "Green Component":
+					current[1] = v
+				case "Blue Component":
+					current[2] = v
+				}
+			}
+		}
+	}
+
+	hex := func(name string) string {
+		c, ok := colors[name]
+		if !ok {
+			return ""
+		}
+		var rgb [3]uint8
+		for i, v := range c {
+			rgb[i] = uint8(math.Round(min(max(v, 0), 1) * 255)) //nolint: gosec
+		}
+		return fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2])
+	}
+
+	p := Palette{
+		Foreground: hex("Foreground Color"),
+		Background: hex("Background Color"),
+		Cursor:     hex("Cursor Color"),
+	}
+	for i := range 16 {
+		if c := hex(fmt.Sprintf("Ansi %d Color", i)); c != "" {
+			p.setColor(i, c)
+		}
+	}
+	if len(colors) == 0 {
+		return Palette{}, errNoColors
+	}
+	return p, nil
+}
+
+// setColor sets the ith ANSI color of the palette.
+func (p *Palette) setColor(i int, c string) {
+	if i >= len(p.Colors) {
+		p.Colors = append(p.Colors, make([]string, i+1-len(p.Colors))...)
+	}
+	p.Colors[i] = c
+}
+
+func isSet(s string) bool {
+	return s != ""
+}
+
+// stripComment removes the comment at the end of a TOML line.
+func stripComment(line string) string {
+	inString := false
+	for i, r := range line {
+		switch r {
+		case '"', '\'':
+			inString = !inString
+		case '#':
+			if !inString {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
@@ -0,0 +1,181 @@
+package freeze
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTerminalPalette(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(*Config)
+		index  int
+		want   string
+	}{
+		{"default", func(*Config) {}, 1, "#D74E6F"},
+		{"theme", func(c *Config) { c.Theme = "dracula" }, 1, "#ff5555"},
+		{"unknown theme", func(c *Config) { c.Theme = "abap" }, 1, "#D74E6F"},
+		{"name", func(c *Config) { c.Theme = "dracula"; c.Palette.Name = "nord" }, 1, "#bf616a"},
+		{"colors", func(c *Config) { c.Palette.Colors = []string{"#000000", "#123456"} }, 1, "#123456"},
+		{"kept colors", func(c *Config) { c.Palette.Colors = []string{"#000000", "#123456"} }, 2, "#31BB71"},
+		{"color cube", func(*Config) {}, 196, "#ff0000"},
+		{"grayscale", func(*Config) {}, 244, "#808080"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tc.config(&config)
+			p, err := terminalPalette(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.colors()[tc.index]; got != tc.want {
+				t.Errorf("expected color %d to be %s, got %s", tc.index, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestTerminalPaletteErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		palette Palette
+	}{
+		{"unknown name", Palette{Name: "nope"}},
+		{"too many colors", Palette{Colors: make([]string, 17)}},
+		{"invalid color", Palette{Foreground: "red"}},
+		{"unknown file", Palette{File: "colors.yml"}},
+		{"unknown adaptation", Palette{Adapt: "invert"}},
+		{"missing file", Palette{File: "missing.toml"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Palette = tc.palette
+			if _, err := terminalPalette(config); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestImportPalette(t *testing.T) {
+	tests := []struct {
+		file     string
+		contents string
+	}{
+		{"alacritty.toml", `
+[colors.primary]
+foreground = "#c0c0c0" # text
+background = '0x101010'
+
+[colors.cursor]
+cursor = "#ffffff"
+
+[colors.normal]
+black = "#000000"
+red = "#ff0000"
+
+[colors.bright]
+red = "#ff8080"
+`},
+		{"kitty.conf", `
+# colors
+foreground #c0c0c0
+background #101010
+cursor     #ffffff
+color0     #000000
+color1     #ff0000
+color9     #ff8080
+`},
+		{"scheme.json", `{
+  "schemes": [{
+    "name": "Test",
+    "foreground": "#C0C0C0",
+    "background": "#101010",
+    "cursorColor": "#FFFFFF",
+    "black": "#000000",
+    "red": "#FF0000",
+    "brightRed": "#FF8080"
+  }]
+}`},
+		{"test.itermcolors", `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Ansi 0 Color</key>
+	<dict>
+		<key>Blue Component</key><real>0</real>
+		<key>Green Component</key><real>0</real>
+		<key>Red Component</key><real>0</real>
+	</dict>
+	<key>Ansi 1 Color</key>
+	<dict>
+		<key>Blue Component</key><real>0</real>
+		<key>Green Component</key><real>0</real>
+		<key>Red Component</key><real>1</real>
+	</dict>
+	<key>Ansi 9 Color</key>
+	<dict>
+		<key>Blue Component</key><real>0.5019608</real>
+		<key>Green Component</key><real>0.5019608</real>
+		<key>Red Component</key><real>1</real>
+	</dict>
+	<key>Background Color</key>
+	<dict>
+		<key>Blue Component</key><real>0.0627451</real>
+		<key>Green Component</key><real>0.0627451</real>
+		<key>Red Component</key><real>0.0627451</real>
+	</dict>
+	<key>Cursor Color</key>
+	<dict>
+		<key>Blue Component</key><real>1</real>
+		<key>Green Component</key><real>1</real>
+		<key>Red Component</key><real>1</real>
+	</dict>
+	<key>Foreground Color</key>
+	<dict>
+		<key>Blue Component</key><real>0.7529412</real>
+		<key>Green Component</key><real>0.7529412</real>
+		<key>Red Component</key><real>0.7529412</real>
+	</dict>
+</dict>
+</plist>
+`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.file, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.file)
+			if err := os.WriteFile(path, []byte(tc.contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			config := DefaultConfig()
+			config.Palette.File = path
+			p, err := terminalPalette(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			colors := p.colors()
+			for _, c := range []struct{ got, want string }{
+				{strings.ToLower(p.Foreground), "#c0c0c0"},
+				{strings.ToLower(p.Background), "#101010"},
+				{strings.ToLower(p.Cursor), "#ffffff"},
+				{strings.ToLower(colors[0]), "#000000"},
+				{strings.ToLower(colors[1]), "#ff0000"},
+				{strings.ToLower(colors[9]), "#ff8080"},
+				// colors missing from the file keep the default palette.
+				{colors[2], "#31BB71"},
+			} {
+				if c.got != c.want {
+					t.Errorf("expected %s, got %s", c.want, c.got)
+				}
+			}
+		})
+	}
+}
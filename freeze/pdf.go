@@ -0,0 +1,211 @@
+package freeze
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/charmbracelet/freeze/font"
+	"github.com/go-pdf/fpdf"
+)
+
+const pdfFontFamily = "freeze"
+
+// encodePDF draws the rendered document into a single page PDF. Text is
+// written with the embedded font, so it stays selectable and searchable.
+func encodePDF(config Config, doc *etree.Document) ([]byte, error) {
+	s := parseScene(doc)
+	if s.width <= 0 || s.height <= 0 {
+		return nil, fmt.Errorf("invalid dimensions %.2fx%.2f", s.width, s.height)
+	}
+
+	ttf, err := ttfFont(config)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		UnitStr: "pt",
+		Size:    fpdf.SizeType{Wd: s.width, Ht: s.height},
+	})
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetCreator("freeze", true)
+	pdf.AddUTF8FontFromBytes(pdfFontFamily, "", ttf)
+	pdf.AddPage()
+
+	drawPDFShadow(pdf, s)
+
+	t := s.terminal
+	style := "F"
+	setPDFFillColor(pdf, t.fill)
+	if t.strokeWidth > 0 && t.stroke != "" {
+		style = "FD"
+		setPDFDrawColor(pdf, t.stroke)
+		pdf.SetLineWidth(t.strokeWidth)
+	}
+	drawPDFRoundedRect(pdf, t.x, t.y, t.width, t.height, t.radius, style)
+
+	for _, c := range s.circles {
+		setPDFFillColor(pdf, c.fill)
+		pdf.Circle(c.x, c.y, c.radius, "F")
+	}
+
+	if s.clip != nil {
+		pdf.ClipRect(s.clip.x, s.clip.y, s.clip.width, s.clip.height, false)
+	}
+
+	for _, bg := range s.backgrounds {
+		setPDFFillColor(pdf, bg.fill)
+		pdf.Rect(bg.x, bg.y, bg.width, bg.height, "F")
+	}
+
+	pdf.SetFont(pdfFontFamily, "", s.fontSize)
+	for _, line := range s.lines {
+		x := line.x
+		for _, span := range line.spans {
+			x += span.dx
+			if span.text == "" {
+				continue
+			}
+			width := pdf.GetStringWidth(span.text)
+			setPDFTextColor(pdf, span.fill)
+			pdf.SetAlpha(min(max(span.opacity, 0), 1), "Normal")
+			if span.italic {
+				pdf.TransformBegin()
+				pdf.TransformSkewX(-12, x, line.y)
+			}
+			if span.bold {
+				// the font has no bold variant, so outline the glyphs as well
+				// as filling them.
+				setPDFDrawColor(pdf, span.fill)
+				pdf.SetLineWidth(s.fontSize / 32)
+				pdf.SetTextRenderingMode(2)
+			}
+			pdf.Text(x, line.y, span.text)
+			if span.bold {
+				pdf.SetTextRenderingMode(0)
+			}
+			if span.italic {
+				pdf.TransformEnd()
+			}
+			if span.underline || span.strike {
+				setPDFDrawColor(pdf, span.fill)
+				pdf.SetLineWidth(s.fontSize / 16)
+			}
+			if span.underline {
+				pdf.Line(x, line.y+s.fontSize/8, x+width, line.y+s.fontSize/8)
+			}
+			if span.link != "" {
+				pdf.LinkString(x, line.y-s.fontSize, width, s.fontSize*1.3, span.link)
+			}
+			if span.strike {
+				pdf.Line(x, line.y-s.fontSize/4, x+width, line.y-s.fontSize/4)
+			}
+			x += width
+		}
+	}
+	pdf.SetAlpha(1, "Normal")
+
+	for _, u := range s.underlines {
+		setPDFFillColor(pdf, u.color)
+		for _, polygon := range u.polygons() {
+			points := make([]fpdf.PointType, len(polygon))
+			for i, p := range polygon {
+				points[i] = fpdf.PointType{X: p.x, Y: p.y}
+			}
+			pdf.Polygon(points, "F")
+		}
+	}
+
+	if s.clip != nil {
+		pdf.ClipEnd()
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+	return buf.Bytes(), nil
+}
+
+// ttfFont returns the TrueType font to embed. PDFs and the native renderer
+// cannot use the system fonts the SVG falls back to, so anything but a TrueType
+// font file uses the bundled JetBrains Mono.
+func ttfFont(config Config) ([]byte, error) {
+	if config.Font.File != "" && strings.EqualFold(filepath.Ext(config.Font.File), ".ttf") {
+		b, err := os.ReadFile(config.Font.File)
+		if err != nil {
+			return nil, fmt.Errorf("invalid font file: %w", err)
+		}
+		return b, nil
+	}
+	if !config.Font.Ligatures {
+		return font.JetBrainsMonoNLTTF, nil
+	}
+	return font.JetBrainsMonoTTF, nil
+}
+
+// drawPDFShadow approximates the Gaussian blur of the SVG drop shadow with
+// a stack of translucent rounded rectangles, since PDF has no blur filter.
+func drawPDFShadow(pdf *fpdf.Fpdf, s scene) {
+	if s.shadow == nil {
+		return
+	}
+
+	const steps = 12
+	t := s.terminal
+	pdf.SetFillColor(0, 0, 0)
+	for i := steps; i > 0; i-- {
+		spread := s.shadow.blur * float64(i) / steps
+		pdf.SetAlpha(0.5/steps, "Normal")
+		drawPDFRoundedRect(pdf,
+			t.x+s.shadow.x-spread, t.y+s.shadow.y-spread,
+			t.width+spread*2, t.height+spread*2,
+			t.radius+spread, "F",
+		)
+	}
+	pdf.SetAlpha(1, "Normal")
+}
+
+// drawPDFRoundedRect draws a rounded rectangle path. Unlike
+// Fpdf.RoundedRect, it leaves the graphics state stack balanced.
+func drawPDFRoundedRect(pdf *fpdf.Fpdf, x, y, w, h, r float64, style string) {
+	r = min(r, w/2, h/2)
+	if r <= 0 {
+		pdf.Rect(x, y, w, h, style)
+		return
+	}
+
+	// control point distance approximating a quarter circle with a Bézier curve.
+	k := r * 0.5523
+	pdf.MoveTo(x+r, y)
+	pdf.LineTo(x+w-r, y)
+	pdf.CurveBezierCubicTo(x+w-r+k, y, x+w, y+r-k, x+w, y+r)
+	pdf.LineTo(x+w, y+h-r)
+	pdf.CurveBezierCubicTo(x+w, y+h-r+k, x+w-r+k, y+h, x+w-r, y+h)
+	pdf.LineTo(x+r, y+h)
+	pdf.CurveBezierCubicTo(x+r-k, y+h, x, y+h-r+k, x, y+h-r)
+	pdf.LineTo(x, y+r)
+	pdf.CurveBezierCubicTo(x, y+r-k, x+r-k, y, x+r, y)
+	pdf.ClosePath()
+	pdf.DrawPath(style)
+}
+
+func setPDFFillColor(pdf *fpdf.Fpdf, hex string) {
+	c, _ := parseHexColor(hex)
+	pdf.SetFillColor(int(c.R), int(c.G), int(c.B))
+}
+
+func setPDFDrawColor(pdf *fpdf.Fpdf, hex string) {
+	c, _ := parseHexColor(hex)
+	pdf.SetDrawColor(int(c.R), int(c.G), int(c.B))
+}
+
+func setPDFTextColor(pdf *fpdf.Fpdf, hex string) {
+	c, _ := parseHexColor(hex)
+	pdf.SetTextColor(int(c.R), int(c.G), int(c.B))
+}
@@ -0,0 +1,43 @@
+package freeze
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestEncodePDF(t *testing.T) {
+	config := DefaultConfig()
+	config.Language = "go"
+	config.Window = true
+	config.Output = "main.pdf"
+
+	doc, err := Render(context.Background(), config, strings.NewReader("package main\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := parseScene(doc)
+	if len(s.lines) != 1 || len(s.circles) != 3 {
+		t.Fatalf("expected 1 line and 3 window controls, got %d and %d", len(s.lines), len(s.circles))
+	}
+	var text string
+	for _, span := range s.lines[0].spans {
+		text += span.text
+	}
+	if text != "package main" {
+		t.Fatalf("expected %q, got %q", "package main", text)
+	}
+
+	b, err := Encode(context.Background(), config, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b, []byte("%PDF-")) {
+		t.Fatal("expected a PDF document")
+	}
+	if !bytes.Contains(b, []byte("/FontFile2")) {
+		t.Fatal("expected the font to be embedded")
+	}
+}
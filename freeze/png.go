@@ -0,0 +1,168 @@
+package freeze
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os/exec"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/charmbracelet/freeze/font"
+	"github.com/charmbracelet/freeze/svg"
+	"github.com/kanrichan/resvg-go"
+)
+
+// rsvgRasterizer converts documents with rsvg-convert, which is fast but
+// has to be installed.
+type rsvgRasterizer struct{}
+
+func (rsvgRasterizer) Name() string { return "rsvg" }
+
+func (rsvgRasterizer) Rasterize(ctx context.Context, _ Config, doc *etree.Document) (image.Image, error) {
+	b, err := libsvgConvert(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	return png.Decode(bytes.NewReader(b)) //nolint: wrapcheck
+}
+
+// resvgRasterizer converts documents with resvg running in WebAssembly, so
+// it works everywhere freeze does.
+type resvgRasterizer struct{}
+
+func (resvgRasterizer) Name() string { return "resvg" }
+
+func (resvgRasterizer) Rasterize(ctx context.Context, config Config, doc *etree.Document) (image.Image, error) {
+	var w resvgWorker
+	defer w.Close() //nolint: errcheck
+	return w.Rasterize(ctx, config, doc)
+}
+
+// resvgWorker converts documents like resvgRasterizer, but starts resvg on
+// first use and keeps it for the documents after that until it is closed.
+type resvgWorker struct {
+	worker *resvg.Worker
+	fontdb *resvg.FontDB
+}
+
+func (*resvgWorker) Name() string { return Resvg.Name() }
+
+func (w *resvgWorker) Rasterize(ctx context.Context, _ Config, doc *etree.Document) (image.Image, error) {
+	if w.worker == nil {
+		if err := w.start(ctx); err != nil {
+			return nil, err
+		}
+	}
+	width, height := svg.GetDimensions(doc.Root())
+	b, err := w.convert(doc, float64(width), float64(height))
+	if err != nil {
+		return nil, err
+	}
+	return png.Decode(bytes.NewReader(b)) //nolint: wrapcheck
+}
+
+// Close stops resvg, if it was started.
+func (w *resvgWorker) Close() error {
+	if w.worker == nil {
+		return nil
+	}
+	err := w.worker.Close()
+	w.worker, w.fontdb = nil, nil
+	return err //nolint: wrapcheck
+}
+
+// nativeRasterizer converts documents with freeze's own rasterizer.
+type nativeRasterizer struct{}
+
+func (nativeRasterizer) Name() string { return "native" }
+
+func (nativeRasterizer) Rasterize(_ context.Context, config Config, doc *etree.Document) (image.Image, error) {
+	return nativeRaster(config, doc)
+}
+
+func libsvgConvert(ctx context.Context, doc *etree.Document) ([]byte, error) {
+	_, err := exec.LookPath("rsvg-convert")
+	if err != nil {
+		return nil, fmt.Errorf("%w: rsvg-convert is not installed", ErrRendererUnavailable)
+	}
+
+	svg, err := doc.WriteToBytes()
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+
+	// rsvg-convert is installed use that to convert the SVG to PNG,
+	// since it is faster.
+	var out, stderr bytes.Buffer
+	rsvgConvert := exec.CommandContext(ctx, "rsvg-convert")
+	rsvgConvert.Stdin = bytes.NewReader(svg)
+	rsvgConvert.Stdout = &out
+	rsvgConvert.Stderr = &stderr
+	err = rsvgConvert.Run()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err //nolint: wrapcheck
+	}
+	return out.Bytes(), nil
+}
+
+func (w *resvgWorker) start(ctx context.Context) error {
+	worker, err := resvg.NewDefaultWorker(ctx)
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+	fontdb, err := worker.NewFontDBDefault()
+	if err == nil {
+		err = fontdb.LoadFontData(font.JetBrainsMonoTTF)
+	}
+	if err == nil {
+		err = fontdb.LoadFontData(font.JetBrainsMonoNLTTF)
+	}
+	if err != nil {
+		worker.Close() //nolint: errcheck
+		return err     //nolint: wrapcheck
+	}
+	w.worker, w.fontdb = worker, fontdb
+	return nil
+}
+
+func (w *resvgWorker) convert(doc *etree.Document, width, height float64) ([]byte, error) {
+	svg, err := doc.WriteToBytes()
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+
+	pixmap, err := w.worker.NewPixmap(uint32(width), uint32(height))
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+	defer pixmap.Close() //nolint: errcheck
+
+	tree, err := w.worker.NewTreeFromData(svg, &resvg.Options{
+		Dpi:                192,
+		ShapeRenderingMode: resvg.ShapeRenderingModeGeometricPrecision,
+		TextRenderingMode:  resvg.TextRenderingModeOptimizeLegibility,
+		ImageRenderingMode: resvg.ImageRenderingModeOptimizeQuality,
+		DefaultSizeWidth:   float32(width),
+		DefaultSizeHeight:  float32(height),
+	})
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+	defer tree.Close() //nolint: errcheck
+
+	err = tree.ConvertText(w.fontdb)
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+	err = tree.Render(resvg.TransformIdentity(), pixmap)
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+	return pixmap.EncodePNG() //nolint: wrapcheck
+}
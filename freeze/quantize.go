@@ -0,0 +1,150 @@
+package freeze
+
+import (
+	"cmp"
+	"image"
+	"image/color"
+	"slices"
+)
+
+// quantize reduces the image to an indexed image of at most n colors using
+// median cut. Screenshots are mostly flat colors, so the result is hard to
+// tell apart from the original while compressing much better.
+func quantize(img image.Image, n int) *image.Paletted {
+	n = clamp(n, 2, 256)
+	bounds := img.Bounds()
+
+	histogram := map[color.NRGBA]int{}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA) //nolint: forcetypeassert
+			histogram[c]++
+		}
+	}
+
+	colors := make([]weightedColor, 0, len(histogram))
+	for c, count := range histogram {
+		colors = append(colors, weightedColor{c, count})
+	}
+	// maps are iterated in random order, but the same image has to give the
+	// same palette for the output to be reproducible.
+	slices.SortFunc(colors, func(a, b weightedColor) int {
+		return cmp.Compare(packColor(a.NRGBA), packColor(b.NRGBA))
+	})
+
+	var p color.Palette
+	if len(colors) <= n {
+		p = make(color.Palette, 0, len(colors))
+		for _, c := range colors {
+			p = append(p, c.NRGBA)
+		}
+	} else {
+		p = medianCut(colors, n)
+	}
+
+	dst := image.NewPaletted(image.Rect(0, 0, bounds.Dx(), bounds.Dy()), p)
+	cache := map[color.NRGBA]uint8{}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA) //nolint: forcetypeassert
+			i, ok := cache[c]
+			if !ok {
+				i = uint8(p.Index(c)) //nolint: gosec
+				cache[c] = i
+			}
+			dst.SetColorIndex(x-bounds.Min.X, y-bounds.Min.Y, i)
+		}
+	}
+	return dst
+}
+
+type weightedColor struct {
+	color.NRGBA
+	count int
+}
+
+// packColor returns the channels of the color packed into one number, to
+// order colors by.
+func packColor(c color.NRGBA) uint32 {
+	return uint32(c.R)<<24 | uint32(c.G)<<16 | uint32(c.B)<<8 | uint32(c.A)
+}
+
+func channel(c color.NRGBA, ch int) uint8 {
+	switch ch {
+	case 0:
+		return c.R
+	case 1:
+		return c.G
+	case 2:
+		return c.B
+	default:
+		return c.A
+	}
+}
+
+// medianCut splits the colors into n boxes, always splitting the box with the
+// widest channel range at its weighted median, and returns the weighted
+// average color of each box.
+func medianCut(colors []weightedColor, n int) color.Palette {
+	boxes := [][]weightedColor{colors}
+	for len(boxes) < n {
+		best, bestChannel, bestRange := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			for ch := range 4 {
+				lo, hi := uint8(255), uint8(0)
+				for _, c := range box {
+					v := channel(c.NRGBA, ch)
+					lo = min(lo, v)
+					hi = max(hi, v)
+				}
+				if r := int(hi) - int(lo); r > bestRange {
+					best, bestChannel, bestRange = i, ch, r
+				}
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		box := boxes[best]
+		slices.SortStableFunc(box, func(a, b weightedColor) int {
+			return cmp.Compare(channel(a.NRGBA, bestChannel), channel(b.NRGBA, bestChannel))
+		})
+		total := 0
+		for _, c := range box {
+			total += c.count
+		}
+		split, acc := 1, 0
+		for i, c := range box {
+			acc += c.count
+			if acc >= total/2 {
+				split = clamp(i+1, 1, len(box)-1)
+				break
+			}
+		}
+		boxes[best] = box[:split]
+		boxes = append(boxes, box[split:])
+	}
+
+	p := make(color.Palette, 0, len(boxes))
+	for _, box := range boxes {
+		var r, g, b, a, total int
+		for _, c := range box {
+			r += int(c.R) * c.count
+			g += int(c.G) * c.count
+			b += int(c.B) * c.count
+			a += int(c.A) * c.count
+			total += c.count
+		}
+		p = append(p, color.NRGBA{
+			R: uint8(r / total), //nolint: gosec
+			G: uint8(g / total), //nolint: gosec
+			B: uint8(b / total), //nolint: gosec
+			A: uint8(a / total), //nolint: gosec
+		})
+	}
+	return p
+}
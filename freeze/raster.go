@@ -0,0 +1,393 @@
+package freeze
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/beevik/etree"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// italicSlant is the horizontal shift per unit of height used to slant
+// italic text, matching the 12 degree skew of the PDF output.
+var italicSlant = math.Tan(12 * math.Pi / 180)
+
+// nativeRaster draws the document without any external renderer. It only
+// understands the subset of SVG that Render emits, but the result depends on
+// nothing but the document and the font, so it is the same on every machine.
+func nativeRaster(config Config, doc *etree.Document) (*image.RGBA, error) {
+	s := parseScene(doc)
+	w, h := int(math.Ceil(s.width)), int(math.Ceil(s.height))
+	if w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("invalid dimensions %.2fx%.2f", s.width, s.height)
+	}
+
+	ttf, err := ttfFont(config)
+	if err != nil {
+		return nil, err
+	}
+	f, err := sfnt.Parse(ttf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid font file: %w", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	bounds := img.Bounds()
+
+	if s.shadow != nil {
+		drawRasterShadow(img, s)
+	}
+
+	// the stroke is centered on the edge of the terminal, so draw the outer
+	// half of it and leave the inner half uncovered by the fill.
+	t := s.terminal
+	if c, ok := rasterColor(t.stroke); ok && t.strokeWidth > 0 {
+		sw := t.strokeWidth / 2
+		fill(img, bounds, c, func(p *pen) {
+			p.roundedRect(t.x-sw, t.y-sw, t.width+sw*2, t.height+sw*2, t.radius+sw)
+		})
+		t.x, t.y, t.width, t.height, t.radius = t.x+sw, t.y+sw, t.width-sw*2, t.height-sw*2, max(t.radius-sw, 0)
+	}
+	if c, ok := rasterColor(t.fill); ok {
+		fill(img, bounds, c, func(p *pen) {
+			p.roundedRect(t.x, t.y, t.width, t.height, t.radius)
+		})
+	}
+
+	for _, circle := range s.circles {
+		if c, ok := rasterColor(circle.fill); ok {
+			fill(img, bounds, c, func(p *pen) {
+				p.roundedRect(circle.x-circle.radius, circle.y-circle.radius, circle.radius*2, circle.radius*2, circle.radius)
+			})
+		}
+	}
+
+	clip := bounds
+	if s.clip != nil {
+		clip = clip.Intersect(image.Rect(
+			int(math.Floor(s.clip.x)), int(math.Floor(s.clip.y)),
+			int(math.Ceil(s.clip.x+s.clip.width)), int(math.Ceil(s.clip.y+s.clip.height)),
+		))
+	}
+
+	for _, bg := range s.backgrounds {
+		if c, ok := rasterColor(bg.fill); ok {
+			fill(img, clip, c, func(p *pen) {
+				p.roundedRect(bg.x, bg.y, bg.width, bg.height, 0)
+			})
+		}
+	}
+
+	text := &rasterText{img: img, clip: clip, font: f, size: s.fontSize}
+	for _, line := range s.lines {
+		x := line.x
+		for _, span := range line.spans {
+			x += span.dx
+			c, ok := rasterColor(span.fill)
+			if !ok || span.text == "" {
+				continue
+			}
+			c.A = uint8(float64(c.A) * min(max(span.opacity, 0), 1)) //nolint: gosec
+			width, err := text.draw(span, x, line.y, c)
+			if err != nil {
+				return nil, err
+			}
+			thickness := s.fontSize / 16
+			if span.underline {
+				fill(img, clip, c, func(p *pen) {
+					p.roundedRect(x, line.y+s.fontSize/8-thickness/2, width, thickness, 0)
+				})
+			}
+			if span.strike {
+				fill(img, clip, c, func(p *pen) {
+					p.roundedRect(x, line.y-s.fontSize/4-thickness/2, width, thickness, 0)
+				})
+			}
+			x += width
+		}
+	}
+
+	for _, u := range s.underlines {
+		c, ok := rasterColor(u.color)
+		if !ok {
+			continue
+		}
+		for _, polygon := range u.polygons() {
+			fill(img, clip, c, func(p *pen) {
+				p.polygon(polygon)
+			})
+		}
+	}
+
+	return img, nil
+}
+
+// drawRasterShadow draws the drop shadow of the terminal window: its shape,
+// blurred and offset, in black.
+func drawRasterShadow(img *image.RGBA, s scene) {
+	t := s.terminal
+	mask := image.NewAlpha(img.Bounds())
+	z := vector.NewRasterizer(mask.Bounds().Dx(), mask.Bounds().Dy())
+	p := &pen{z: z}
+	p.roundedRect(t.x+s.shadow.x, t.y+s.shadow.y, t.width, t.height, t.radius)
+	z.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+
+	blur(mask, s.shadow.blur)
+	draw.DrawMask(img, img.Bounds(), image.Black, image.Point{}, mask, image.Point{}, draw.Over)
+}
+
+// blur approximates a Gaussian blur of the mask with three box blurs.
+func blur(mask *image.Alpha, sigma float64) {
+	r := int(math.Round((math.Sqrt(4*sigma*sigma+1) - 1) / 2))
+	if r <= 0 {
+		return
+	}
+	for range 3 {
+		boxBlurRows(mask, r)
+		boxBlurColumns(mask, r)
+	}
+}
+
+// boxBlurRows averages each value of the mask with the r values on either
+// side of it on the same row. Values outside of the mask count as
+// transparent.
+func boxBlurRows(mask *image.Alpha, r int) {
+	w, h := mask.Bounds().Dx(), mask.Bounds().Dy()
+	n := 2*r + 1
+	row := make([]uint8, w)
+	for y := range h {
+		pix := mask.Pix[y*mask.Stride : y*mask.Stride+w]
+		sum := 0
+		for x := 0; x < r && x < w; x++ {
+			sum += int(pix[x])
+		}
+		for x := range w {
+			if x+r < w {
+				sum += int(pix[x+r])
+			}
+			row[x] = uint8(sum / n) //nolint: gosec
+			if x-r >= 0 {
+				sum -= int(pix[x-r])
+			}
+		}
+		copy(pix, row)
+	}
+}
+
+// boxBlurColumns is boxBlurRows for columns. It walks the mask row by row,
+// keeping a running sum for every column, to stay cache friendly.
+func boxBlurColumns(mask *image.Alpha, r int) {
+	w, h := mask.Bounds().Dx(), mask.Bounds().Dy()
+	n := 2*r + 1
+	sums := make([]int, w)
+	for y := 0; y < r && y < h; y++ {
+		for x, v := range mask.Pix[y*mask.Stride : y*mask.Stride+w] {
+			sums[x] += int(v)
+		}
+	}
+	// rows are overwritten as we go, so keep the original values of the
+	// last r+1 rows around to subtract them from the sums later.
+	ring := make([][]uint8, r+1)
+	for i := range ring {
+		ring[i] = make([]uint8, w)
+	}
+	for y := range h {
+		pix := mask.Pix[y*mask.Stride : y*mask.Stride+w]
+		if y+r < h {
+			for x, v := range mask.Pix[(y+r)*mask.Stride : (y+r)*mask.Stride+w] {
+				sums[x] += int(v)
+			}
+		}
+		copy(ring[y%(r+1)], pix)
+		for x := range pix {
+			pix[x] = uint8(sums[x] / n) //nolint: gosec
+		}
+		if y-r >= 0 {
+			for x, v := range ring[(y-r)%(r+1)] {
+				sums[x] -= int(v)
+			}
+		}
+	}
+}
+
+// rasterText draws text with the outlines of the font.
+type rasterText struct {
+	img  *image.RGBA
+	clip image.Rectangle
+	font *sfnt.Font
+	size float64
+	buf  sfnt.Buffer
+}
+
+// draw draws the span with its baseline starting at x, y and returns its
+// width.
+func (t *rasterText) draw(span sceneSpan, x, y float64, c color.Color) (float64, error) {
+	ppem := fixed.Int26_6(math.Round(t.size * 64))
+	start := x
+	for _, r := range span.text {
+		idx, err := t.font.GlyphIndex(&t.buf, r)
+		if err != nil {
+			return 0, err //nolint: wrapcheck
+		}
+		advance, err := t.font.GlyphAdvance(&t.buf, idx, ppem, font.HintingNone)
+		if err != nil {
+			return 0, err //nolint: wrapcheck
+		}
+		segments, err := t.font.LoadGlyph(&t.buf, idx, ppem, nil)
+		if err != nil {
+			return 0, err //nolint: wrapcheck
+		}
+		t.glyph(segments, x, y, span.italic, c)
+		if span.bold {
+			// the font has no bold variant, so thicken the glyph instead.
+			t.glyph(segments, x+t.size/32, y, span.italic, c)
+		}
+		x += float64(advance) / 64
+	}
+	return x - start, nil
+}
+
+func (t *rasterText) glyph(segments sfnt.Segments, x, y float64, italic bool, c color.Color) {
+	if len(segments) == 0 {
+		return
+	}
+
+	point := func(p fixed.Point26_6) (float64, float64) {
+		px, py := float64(p.X)/64, float64(p.Y)/64
+		if italic {
+			px -= py * italicSlant
+		}
+		return x + px, y + py
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, seg := range segments {
+		for _, arg := range seg.Args[:segmentArgs(seg.Op)] {
+			px, py := point(arg)
+			minX, minY = min(minX, px), min(minY, py)
+			maxX, maxY = max(maxX, px), max(maxY, py)
+		}
+	}
+	bounds := image.Rect(
+		int(math.Floor(minX)), int(math.Floor(minY)),
+		int(math.Ceil(maxX)), int(math.Ceil(maxY)),
+	)
+
+	fill(t.img, t.clip.Intersect(bounds), c, func(p *pen) {
+		for _, seg := range segments {
+			switch seg.Op {
+			case sfnt.SegmentOpMoveTo:
+				p.moveTo(point(seg.Args[0]))
+			case sfnt.SegmentOpLineTo:
+				p.lineTo(point(seg.Args[0]))
+			case sfnt.SegmentOpQuadTo:
+				bx, by := point(seg.Args[0])
+				cx, cy := point(seg.Args[1])
+				p.quadTo(bx, by, cx, cy)
+			case sfnt.SegmentOpCubeTo:
+				bx, by := point(seg.Args[0])
+				cx, cy := point(seg.Args[1])
+				dx, dy := point(seg.Args[2])
+				p.cubeTo(bx, by, cx, cy, dx, dy)
+			}
+		}
+	})
+}
+
+func segmentArgs(op sfnt.SegmentOp) int {
+	switch op {
+	case sfnt.SegmentOpQuadTo:
+		return 2
+	case sfnt.SegmentOpCubeTo:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// fill fills the path drawn by the function within the bounds.
+func fill(img *image.RGBA, bounds image.Rectangle, c color.Color, path func(*pen)) {
+	bounds = bounds.Intersect(img.Bounds())
+	if bounds.Empty() {
+		return
+	}
+	z := vector.NewRasterizer(bounds.Dx(), bounds.Dy())
+	path(&pen{z: z, x: float64(bounds.Min.X), y: float64(bounds.Min.Y)})
+	z.Draw(img, bounds, image.NewUniform(c), image.Point{})
+}
+
+// pen draws paths in image coordinates on a rasterizer whose origin is at
+// x, y.
+type pen struct {
+	z    *vector.Rasterizer
+	x, y float64
+}
+
+func (p *pen) moveTo(x, y float64) {
+	p.z.MoveTo(float32(x-p.x), float32(y-p.y))
+}
+
+func (p *pen) lineTo(x, y float64) {
+	p.z.LineTo(float32(x-p.x), float32(y-p.y))
+}
+
+func (p *pen) quadTo(bx, by, cx, cy float64) {
+	p.z.QuadTo(float32(bx-p.x), float32(by-p.y), float32(cx-p.x), float32(cy-p.y))
+}
+
+func (p *pen) cubeTo(bx, by, cx, cy, dx, dy float64) {
+	p.z.CubeTo(
+		float32(bx-p.x), float32(by-p.y),
+		float32(cx-p.x), float32(cy-p.y),
+		float32(dx-p.x), float32(dy-p.y),
+	)
+}
+
+// polygon adds a closed polygon through the points to the path.
+func (p *pen) polygon(points []scenePoint) {
+	for i, point := range points {
+		if i == 0 {
+			p.moveTo(point.x, point.y)
+		} else {
+			p.lineTo(point.x, point.y)
+		}
+	}
+	if len(points) > 0 {
+		p.lineTo(points[0].x, points[0].y)
+	}
+}
+
+// roundedRect adds a rounded rectangle to the path.
+func (p *pen) roundedRect(x, y, w, h, r float64) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	r = min(r, w/2, h/2)
+
+	// control point distance approximating a quarter circle with a Bézier curve.
+	k := r * 0.5523
+	p.moveTo(x+r, y)
+	p.lineTo(x+w-r, y)
+	p.cubeTo(x+w-r+k, y, x+w, y+r-k, x+w, y+r)
+	p.lineTo(x+w, y+h-r)
+	p.cubeTo(x+w, y+h-r+k, x+w-r+k, y+h, x+w-r, y+h)
+	p.lineTo(x+r, y+h)
+	p.cubeTo(x+r-k, y+h, x, y+h-r+k, x, y+h-r)
+	p.lineTo(x, y+r)
+	p.cubeTo(x, y+r-k, x+r-k, y, x+r, y)
+	p.z.ClosePath()
+}
+
+// rasterColor parses a fill color, reporting false for colors that should
+// not be drawn, such as "none".
+func rasterColor(s string) (color.NRGBA, bool) {
+	c, err := parseHexColor(s)
+	return c, err == nil
+}
@@ -0,0 +1,107 @@
+package freeze
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNativeRaster(t *testing.T) {
+	config := DefaultConfig()
+	config.Language = "go"
+	config.Window = true
+	config.Output = "main.png"
+	config.Renderer = "native"
+	config.Margin = []float64{20}
+	config.Shadow = Shadow{Blur: 10, Y: 5}
+	config.Border = Border{Radius: 8, Width: 1, Color: "#515151"}
+
+	encode := func() []byte {
+		doc, err := Render(context.Background(), config, strings.NewReader("package main\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Encode(context.Background(), config, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	b := encode()
+	if !bytes.Equal(b, encode()) {
+		t.Fatal("expected identical output for identical input")
+	}
+
+	doc, err := Render(context.Background(), config, strings.NewReader("package main\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := nativeRaster(config, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := parseScene(doc)
+	if img.Bounds() != image.Rect(0, 0, int(math.Ceil(s.width)), int(math.Ceil(s.height))) {
+		t.Fatalf("unexpected bounds %v for a %.0fx%.0f document", img.Bounds(), s.width, s.height)
+	}
+
+	background := color.RGBA{0x17, 0x17, 0x17, 0xff}
+	center := img.RGBAAt(int(s.terminal.x+s.terminal.width/2), int(s.terminal.y+s.terminal.height-4))
+	if center != background {
+		t.Fatalf("expected the terminal background %v, got %v", background, center)
+	}
+	if corner := img.RGBAAt(0, 0); corner.A != 0 {
+		t.Fatalf("expected a transparent corner, got %v", corner)
+	}
+	if shadow := img.RGBAAt(int(s.terminal.x+s.terminal.width/2), int(s.terminal.y+s.terminal.height+s.shadow.y)); shadow.A == 0 {
+		t.Fatal("expected the shadow below the terminal")
+	}
+
+	var text int
+	for y := int(s.terminal.y); y < int(s.terminal.y+s.terminal.height); y++ {
+		for x := int(s.terminal.x); x < int(s.terminal.x+s.terminal.width); x++ {
+			if c := img.RGBAAt(x, y); c != background && c.R > 0x40 {
+				text++
+			}
+		}
+	}
+	if text == 0 {
+		t.Fatal("expected text to be drawn")
+	}
+}
+
+func TestRasterConceal(t *testing.T) {
+	config := DefaultConfig()
+	config.Language = "ansi"
+	config.Output = "conceal.png"
+	config.Padding = []float64{20}
+
+	doc, err := Render(context.Background(), config, strings.NewReader("\x1b[8mhidden text\x1b[0m\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := parseScene(doc)
+
+	for _, r := range []Rasterizer{Native, Resvg} {
+		t.Run(r.Name(), func(t *testing.T) {
+			img, err := r.Rasterize(context.Background(), config, doc)
+			if err != nil {
+				t.Skip(err)
+			}
+			bounds := img.Bounds()
+			background := color.RGBAModel.Convert(img.At(bounds.Min.X, bounds.Min.Y))
+			for y := int(s.terminal.y); y < int(s.terminal.y+s.terminal.height); y++ {
+				for x := int(s.terminal.x); x < int(s.terminal.x+s.terminal.width); x++ {
+					if c := color.RGBAModel.Convert(img.At(x, y)); c != background {
+						t.Fatalf("expected only the background %v, got %v at %d,%d", background, c, x, y)
+					}
+				}
+			}
+		})
+	}
+}
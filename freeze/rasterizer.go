@@ -0,0 +1,99 @@
+package freeze
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// Rasterizer converts a rendered document into an image.
+type Rasterizer interface {
+	// Name identifies the rasterizer in errors and in the --renderer flag.
+	Name() string
+	// Rasterize draws the document at its own size.
+	Rasterize(ctx context.Context, config Config, doc *etree.Document) (image.Image, error)
+}
+
+// The rasterizers freeze ships with.
+var (
+	// RSVG uses rsvg-convert, if it is installed.
+	RSVG Rasterizer = rsvgRasterizer{}
+	// Resvg uses resvg, compiled to WebAssembly.
+	Resvg Rasterizer = resvgRasterizer{}
+	// Native uses freeze's own rasterizer, which gives the same output on
+	// every machine.
+	Native Rasterizer = nativeRasterizer{}
+)
+
+// ErrRendererUnavailable is returned by rasterizers that cannot run on this
+// machine, e.g. because a program they need is not installed.
+var ErrRendererUnavailable = errors.New("renderer unavailable")
+
+// RasterizeError is returned when a rasterizer fails.
+type RasterizeError struct {
+	// Renderer is the name of the rasterizer that failed.
+	Renderer string
+	Err      error
+}
+
+func (e *RasterizeError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Renderer, e.Err)
+}
+
+func (e *RasterizeError) Unwrap() error {
+	return e.Err
+}
+
+// Rasterizers returns the rasterizers for a comma separated list of names,
+// e.g. "rsvg,resvg". "auto" stands for rsvg followed by resvg.
+func Rasterizers(names string) ([]Rasterizer, error) {
+	var rasterizers []Rasterizer
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "", "auto":
+			rasterizers = append(rasterizers, RSVG, Resvg)
+		case RSVG.Name():
+			rasterizers = append(rasterizers, RSVG)
+		case Resvg.Name():
+			rasterizers = append(rasterizers, Resvg)
+		case Native.Name():
+			rasterizers = append(rasterizers, Native)
+		default:
+			return nil, fmt.Errorf("unknown renderer %q", name)
+		}
+	}
+	return rasterizers, nil
+}
+
+// Rasterize draws the document with the first of the rasterizers that
+// succeeds and returns the image along with the rasterizer that drew it.
+// Without rasterizers, the ones named by Config.Renderer are tried in order.
+//
+// If every rasterizer fails, the returned error joins a *RasterizeError for
+// each of them.
+func Rasterize(ctx context.Context, config Config, doc *etree.Document, rasterizers ...Rasterizer) (image.Image, Rasterizer, error) {
+	if len(rasterizers) == 0 {
+		var err error
+		rasterizers, err = Rasterizers(config.Renderer)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var errs []error
+	for _, r := range rasterizers {
+		img, err := r.Rasterize(ctx, config, doc)
+		if err == nil {
+			return img, r, nil
+		}
+		errs = append(errs, &RasterizeError{Renderer: r.Name(), Err: err})
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, nil, errors.Join(errs...)
+}
@@ -0,0 +1,103 @@
+package freeze
+
+import (
+	"context"
+	"errors"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/beevik/etree"
+)
+
+type failingRasterizer struct{ err error }
+
+func (failingRasterizer) Name() string { return "failing" }
+
+func (r failingRasterizer) Rasterize(context.Context, Config, *etree.Document) (image.Image, error) {
+	return nil, r.err
+}
+
+func TestRasterizers(t *testing.T) {
+	tests := []struct {
+		names string
+		want  []string
+	}{
+		{"", []string{"rsvg", "resvg"}},
+		{"auto", []string{"rsvg", "resvg"}},
+		{"native", []string{"native"}},
+		{"resvg, rsvg", []string{"resvg", "rsvg"}},
+	}
+	for _, tc := range tests {
+		rasterizers, err := Rasterizers(tc.names)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, r := range rasterizers {
+			got = append(got, r.Name())
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%q: expected %v, got %v", tc.names, tc.want, got)
+		}
+	}
+
+	if _, err := Rasterizers("inkscape"); err == nil {
+		t.Error("expected an error for an unknown renderer")
+	}
+}
+
+func TestRasterizeFallback(t *testing.T) {
+	config := DefaultConfig()
+	config.Language = "go"
+	config.Output = "main.png"
+
+	doc, err := Render(context.Background(), config, strings.NewReader("package main\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unavailable := failingRasterizer{ErrRendererUnavailable}
+	img, r, err := Rasterize(context.Background(), config, doc, unavailable, Native)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != Native || img == nil {
+		t.Fatalf("expected the native rasterizer to draw the image, got %v", r)
+	}
+
+	_, _, err = Rasterize(context.Background(), config, doc, unavailable, failingRasterizer{errors.New("boom")})
+	if !errors.Is(err, ErrRendererUnavailable) {
+		t.Fatalf("expected %v, got %v", ErrRendererUnavailable, err)
+	}
+	var rerr *RasterizeError
+	if !errors.As(err, &rerr) || rerr.Renderer != "failing" {
+		t.Fatalf("expected a RasterizeError, got %v", err)
+	}
+}
+
+func TestResvgWorker(t *testing.T) {
+	config := DefaultConfig()
+	config.Language = "go"
+	config.Output = "main.png"
+
+	var w resvgWorker
+	defer w.Close() //nolint: errcheck
+	var worker any
+	for _, input := range []string{"package main\n", "package freeze\n"} {
+		doc, err := Render(context.Background(), config, strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Rasterize(context.Background(), config, doc); err != nil {
+			t.Skip(err)
+		}
+		if worker != nil && worker != any(w.worker) {
+			t.Fatal("expected resvg to be started once for both documents")
+		}
+		worker = w.worker
+	}
+	if err := w.Close(); err != nil || w.worker != nil {
+		t.Fatalf("expected resvg to be stopped, got %v", err)
+	}
+}
@@ -0,0 +1,310 @@
+package freeze
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// scene is the flattened list of shapes and text freeze draws in its SVG
+// output. The non-SVG vector formats are drawn from it, so that they share
+// the layout computed by Render.
+type scene struct {
+	width, height float64
+
+	terminal sceneRect
+	shadow   *sceneShadow
+	clip     *sceneRect
+
+	fontSize float64
+	fill     string
+
+	backgrounds []sceneRect
+	circles     []sceneCircle
+	lines       []sceneLine
+	underlines  []sceneUnderline
+}
+
+type sceneRect struct {
+	x, y, width, height float64
+	radius              float64
+	fill, stroke        string
+	strokeWidth         float64
+}
+
+type sceneCircle struct {
+	x, y, radius float64
+	fill         string
+}
+
+type sceneShadow struct {
+	x, y, blur float64
+}
+
+// sceneUnderline is an underline drawn as a path below the text, for the
+// styles text-decoration cannot draw.
+type sceneUnderline struct {
+	x1, x2, y float64
+	thickness float64
+	period    float64
+	style     string
+	color     string
+	d         string
+	dasharray string
+}
+
+type scenePoint struct {
+	x, y float64
+}
+
+type sceneLine struct {
+	x, y  float64
+	spans []sceneSpan
+}
+
+type sceneSpan struct {
+	text      string
+	fill      string
+	opacity   float64
+	dx        float64
+	bold      bool
+	italic    bool
+	underline bool
+	strike    bool
+	link      string
+}
+
+// parseScene reads the scene back from a document created by Render.
+func parseScene(doc *etree.Document) scene {
+	var s scene
+	root := doc.Root()
+	if root == nil {
+		return s
+	}
+
+	s.width = parseLength(root.SelectAttrValue("width", "0"), 0)
+	s.height = parseLength(root.SelectAttrValue("height", "0"), 0)
+
+	group := root.SelectElement("g")
+	if group != nil {
+		s.fontSize = parseLength(group.SelectAttrValue("font-size", "14px"), 0)
+		s.fill = group.SelectAttrValue("fill", "#000000")
+	}
+
+	if terminal := root.SelectElement("rect"); terminal != nil {
+		s.terminal = parseRect(terminal, s.fontSize)
+		id := strings.TrimSuffix(strings.TrimPrefix(terminal.SelectAttrValue("filter", ""), "url(#"), ")")
+		if filter := root.FindElement("defs/filter[@id='" + id + "']"); id != "" && filter != nil {
+			s.shadow = &sceneShadow{}
+			if blur := filter.SelectElement("feGaussianBlur"); blur != nil {
+				s.shadow.blur = parseLength(blur.SelectAttrValue("stdDeviation", "0"), 0)
+			}
+			if offset := filter.SelectElement("feOffset"); offset != nil {
+				s.shadow.x = parseLength(offset.SelectAttrValue("dx", "0"), 0)
+				s.shadow.y = parseLength(offset.SelectAttrValue("dy", "0"), 0)
+			}
+		}
+	}
+
+	if clip := root.FindElement("defs/clipPath/rect"); clip != nil {
+		r := parseRect(clip, s.fontSize)
+		s.clip = &r
+	}
+
+	for _, controls := range root.SelectElements("svg") {
+		x := parseLength(controls.SelectAttrValue("x", "0"), 0)
+		y := parseLength(controls.SelectAttrValue("y", "0"), 0)
+		for _, c := range controls.SelectElements("circle") {
+			s.circles = append(s.circles, sceneCircle{
+				x:      x + parseLength(c.SelectAttrValue("cx", "0"), 0),
+				y:      y + parseLength(c.SelectAttrValue("cy", "0"), 0),
+				radius: parseLength(c.SelectAttrValue("r", "0"), 0),
+				fill:   c.SelectAttrValue("fill", "#000000"),
+			})
+		}
+	}
+
+	if group == nil {
+		return s
+	}
+
+	for _, rect := range group.SelectElements("rect") {
+		s.backgrounds = append(s.backgrounds, parseRect(rect, s.fontSize))
+	}
+
+	for _, path := range group.SelectElements("path") {
+		if u, ok := parseUnderline(path); ok {
+			s.underlines = append(s.underlines, u)
+		}
+	}
+
+	for _, text := range group.SelectElements("text") {
+		line := sceneLine{
+			x: parseLength(text.SelectAttrValue("x", "0"), s.fontSize),
+			y: parseLength(text.SelectAttrValue("y", "0"), s.fontSize),
+		}
+		base := sceneSpan{fill: s.fill, opacity: 1}
+		for _, child := range text.Child {
+			switch child := child.(type) {
+			case *etree.CharData:
+				span := base
+				span.text = sceneText(child.Data)
+				line.spans = append(line.spans, span)
+			case *etree.Element:
+				if child.Tag != "a" {
+					line.spans = append(line.spans, parseSpan(child, base, s.fontSize))
+					continue
+				}
+				link := base
+				link.link = child.SelectAttrValue("href", "")
+				for _, span := range child.SelectElements("tspan") {
+					line.spans = append(line.spans, parseSpan(span, link, s.fontSize))
+				}
+			}
+		}
+		s.lines = append(s.lines, line)
+	}
+
+	return s
+}
+
+func parseSpan(e *etree.Element, base sceneSpan, fontSize float64) sceneSpan {
+	span := base
+	span.text = sceneText(e.Text())
+	span.fill = e.SelectAttrValue("fill", base.fill)
+	if opacity, err := strconv.ParseFloat(e.SelectAttrValue("fill-opacity", ""), 64); err == nil {
+		span.opacity = opacity
+	}
+	span.dx = parseLength(e.SelectAttrValue("dx", "0"), fontSize)
+	span.bold = e.SelectAttrValue("font-weight", "") == "bold"
+	span.italic = e.SelectAttrValue("font-style", "") == "italic"
+	decoration := e.SelectAttrValue("text-decoration", "")
+	span.underline = strings.Contains(decoration, "underline")
+	span.strike = strings.Contains(decoration, "line-through")
+	return span
+}
+
+// parseUnderline reads an underline from the path drawn by
+// screenWriter.drawUnderline.
+func parseUnderline(e *etree.Element) (sceneUnderline, bool) {
+	u := sceneUnderline{
+		thickness: parseLength(e.SelectAttrValue("stroke-width", "0"), 0),
+		style:     e.SelectAttrValue("data-underline", ""),
+		color:     e.SelectAttrValue("stroke", ""),
+		d:         e.SelectAttrValue("d", ""),
+		dasharray: e.SelectAttrValue("stroke-dasharray", ""),
+	}
+	var nums []float64
+	for _, f := range strings.FieldsFunc(u.d, func(r rune) bool {
+		return strings.ContainsRune("MHQT ,", r)
+	}) {
+		n, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return u, false
+		}
+		nums = append(nums, n)
+	}
+	if u.style == "" || len(nums) < 3 {
+		return u, false
+	}
+
+	u.x1, u.y, u.x2 = nums[0], nums[1], nums[2]
+	switch u.style {
+	case "double":
+		u.y += u.thickness
+	case "curly":
+		if len(nums) < 6 {
+			return u, false
+		}
+		u.x2 = nums[len(nums)-2]
+		u.period = 2 * (nums[4] - u.x1)
+	}
+	return u, true
+}
+
+// polygons returns the shapes covered by the underline, for the formats
+// that fill shapes rather than stroke paths.
+func (u sceneUnderline) polygons() [][]scenePoint {
+	t := u.thickness
+	band := func(x1, x2, y float64) []scenePoint {
+		return []scenePoint{{x1, y - t/2}, {x2, y - t/2}, {x2, y + t/2}, {x1, y + t/2}}
+	}
+	dashes := func(on, off float64) [][]scenePoint {
+		var polygons [][]scenePoint
+		for x := u.x1; x < u.x2; x += on + off {
+			polygons = append(polygons, band(x, min(x+on, u.x2), u.y))
+		}
+		return polygons
+	}
+
+	switch u.style {
+	case "double":
+		return [][]scenePoint{band(u.x1, u.x2, u.y-t), band(u.x1, u.x2, u.y+t)}
+	case "dotted":
+		return dashes(t, t)
+	case "dashed":
+		return dashes(t*3, t*2)
+	case "curly":
+		if u.period <= 0 {
+			return nil
+		}
+		// the path is made of quadratic curves, whose peaks are half as high
+		// as their control points.
+		amplitude := t * 1.5 / 2
+		const steps = 8
+		var top, bottom []scenePoint
+		for x := u.x1; ; x += u.period / 2 / steps {
+			x = min(x, u.x2)
+			y := u.y - amplitude*math.Sin(2*math.Pi*(x-u.x1)/u.period)
+			top = append(top, scenePoint{x, y - t/2})
+			bottom = append(bottom, scenePoint{x, y + t/2})
+			if x >= u.x2 {
+				break
+			}
+		}
+		for i := len(bottom) - 1; i >= 0; i-- {
+			top = append(top, bottom[i])
+		}
+		return [][]scenePoint{top}
+	default:
+		return [][]scenePoint{band(u.x1, u.x2, u.y)}
+	}
+}
+
+// sceneText returns the text of a line, turning the non-breaking spaces
+// chroma escapes spaces and tabs with back into regular spaces.
+func sceneText(s string) string {
+	return strings.ReplaceAll(strings.Trim(s, "\n"), "\u00a0", " ")
+}
+
+func parseRect(e *etree.Element, fontSize float64) sceneRect {
+	return sceneRect{
+		x:           parseLength(e.SelectAttrValue("x", "0"), fontSize),
+		y:           parseLength(e.SelectAttrValue("y", "0"), fontSize),
+		width:       parseLength(e.SelectAttrValue("width", "0"), fontSize),
+		height:      parseLength(e.SelectAttrValue("height", "0"), fontSize),
+		radius:      parseLength(e.SelectAttrValue("rx", "0"), fontSize),
+		fill:        e.SelectAttrValue("fill", ""),
+		stroke:      e.SelectAttrValue("stroke", ""),
+		strokeWidth: parseLength(e.SelectAttrValue("stroke-width", "0"), fontSize),
+	}
+}
+
+// parseLength parses an SVG length in px, em or ch into pixels.
+func parseLength(v string, fontSize float64) float64 {
+	unit := 1.0
+	switch {
+	case strings.HasSuffix(v, "px"):
+		v = strings.TrimSuffix(v, "px")
+	case strings.HasSuffix(v, "em"):
+		v = strings.TrimSuffix(v, "em")
+		unit = fontSize
+	case strings.HasSuffix(v, "ch"):
+		v = strings.TrimSuffix(v, "ch")
+		unit = fontSize / fontHeightToWidthRatio
+	}
+	f, _ := strconv.ParseFloat(v, 64)
+	return f * unit
+}
@@ -1,4 +1,4 @@
-package main
+package freeze
 
 import (
 	"github.com/alecthomas/chroma/v2"
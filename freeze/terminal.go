@@ -0,0 +1,692 @@
+package freeze
+
+import (
+	"cmp"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/charmbracelet/freeze/internal/screen"
+)
+
+// tabStop is the distance between tab stops of the terminal.
+const tabStop = 8
+
+// underlineStyle is the style of an underline, set with the sub-parameter
+// of SGR 4.
+type underlineStyle int
+
+const (
+	underlineNone underlineStyle = iota
+	underlineSingle
+	underlineDouble
+	underlineCurly
+	underlineDotted
+	underlineDashed
+)
+
+func (s underlineStyle) String() string {
+	switch s {
+	case underlineSingle:
+		return "single"
+	case underlineDouble:
+		return "double"
+	case underlineCurly:
+		return "curly"
+	case underlineDotted:
+		return "dotted"
+	case underlineDashed:
+		return "dashed"
+	default:
+		return "none"
+	}
+}
+
+// cellStyle is the style of a cell, set with SGR sequences.
+type cellStyle struct {
+	fg, bg    string
+	bold      bool
+	faint     bool
+	italic    bool
+	underline underlineStyle
+	blink     bool
+	reverse   bool
+	conceal   bool
+	strike    bool
+
+	// underlineColor is the color of the underline, which defaults to the
+	// color of the text.
+	underlineColor string
+}
+
+// cell is a single cell of the terminal screen. Wide characters take two
+// cells, the second of which has no content and no width.
+type cell struct {
+	content string
+	width   int
+	style   cellStyle
+
+	// link is the target of the OSC 8 hyperlink the cell is part of.
+	link string
+}
+
+// blankCell returns an empty cell. Like in most terminals, erased cells keep
+// the current background color.
+func blankCell(style cellStyle) cell {
+	return cell{content: " ", width: 1, style: cellStyle{bg: style.bg}}
+}
+
+// cursorShape is the shape of the cursor.
+type cursorShape int
+
+const (
+	cursorBlock cursorShape = iota
+	cursorUnderline
+	cursorBar
+)
+
+// cursor is where the cursor was left on the screen and how it looked.
+type cursor struct {
+	x, y   int
+	hidden bool
+	shape  cursorShape
+	blink  bool
+}
+
+type savedCursor struct {
+	x, y int
+	pen  cellStyle
+}
+
+// terminal is a virtual terminal. ANSI input is written to a grid of cells,
+// the way a terminal emulator would, so cursor movement, carriage returns,
+// erasing, scrolling and the alternate screen leave the screen a terminal
+// would show.
+//
+// Output of a terminal with a known height, like a recording, scrolls within
+// a screen of that many rows, and the rows scrolled off the top are kept in
+// the scrollback, which is part of the screenshot. Without a height, the
+// screen grows downwards as lines are written, up to maxRows, so nothing
+// scrolls out of the screenshot. Lines only wrap when the terminal has a
+// width. A line feed also returns the cursor to the start of the line, since
+// input files rarely contain the carriage returns a pty adds.
+type terminal struct {
+	width  int
+	height int
+	colors terminalColors
+	rows   [][]cell
+	x, y   int
+	pen    cellStyle
+
+	// scrollback holds the rows scrolled off the top of the main screen.
+	scrollback [][]cell
+
+	// top and bottom are the rows of the scroll region. bottom is -1 until
+	// a region is set.
+	top, bottom int
+
+	// other holds the rows of the screen that is not shown.
+	other    [][]cell
+	alt      bool
+	saved    savedCursor
+	wrapNext bool
+	noWrap   bool
+
+	// link is the target of the open OSC 8 hyperlink, which printed cells
+	// link to.
+	link string
+
+	// cursorHidden, cursorShape and cursorBlink are set by DECTCEM and
+	// DECSCUSR.
+	cursorHidden bool
+	cursorShape  cursorShape
+	cursorBlink  bool
+}
+
+// The cursor stays within maxColumns and maxRows, and the screen within
+// maxRows rows, so that a stray sequence in a log can't grow the screen out
+// of memory.
+const (
+	maxColumns = 4096
+	maxRows    = 10_000
+)
+
+// newTerminal returns a terminal with the given number of columns and rows,
+// which are unlimited when 0, and the given colors.
+func newTerminal(width, height int, colors terminalColors) *terminal {
+	return &terminal{width: width, height: min(height, maxRows), colors: colors, bottom: -1}
+}
+
+// emulate runs the input through a terminal with the given size and colors,
+// and returns the rows of the resulting screen.
+func emulate(input string, width, height int, colors terminalColors) [][]cell {
+	return runTerminal(input, width, height, colors).screen()
+}
+
+// runTerminal runs the input through a terminal with the given size and
+// colors, and returns the terminal.
+func runTerminal(input string, width, height int, colors terminalColors) *terminal {
+	t := newTerminal(width, height, colors)
+	parser := ansi.NewParser()
+	parser.SetHandler(ansi.Handler{
+		Print:     t.Print,
+		Execute:   t.Execute,
+		HandleCsi: t.CsiDispatch,
+		HandleEsc: t.EscDispatch,
+		HandleOsc: t.OscDispatch,
+	})
+	parser.Parse([]byte(input))
+	return t
+}
+
+func init() {
+	// colors don't change the text of the screen.
+	screen.Text = func(input string, width, height int) string {
+		return screenText(emulate(input, width, height, terminalColors{}))
+	}
+}
+
+// screen returns the scrollback of the main screen and the rows of the
+// screen up to the last one with content or the cursor, whichever is further
+// down.
+func (t *terminal) screen() [][]cell {
+	n := t.y + 1
+	for i := len(t.rows) - 1; i >= n; i-- {
+		if len(t.rows[i]) > 0 {
+			n = i + 1
+			break
+		}
+	}
+	scrollback := t.visibleScrollback()
+	rows := make([][]cell, len(scrollback)+n)
+	copy(rows, scrollback)
+	copy(rows[len(scrollback):], t.rows)
+	return rows
+}
+
+// visibleScrollback returns the scrollback shown above the screen, which the
+// alternate screen has none of.
+func (t *terminal) visibleScrollback() [][]cell {
+	if t.alt {
+		return nil
+	}
+	return t.scrollback
+}
+
+// cursor returns the cursor of the terminal, on the rows returned by screen.
+func (t *terminal) cursor() cursor {
+	y := len(t.visibleScrollback()) + t.y
+	return cursor{x: t.x, y: y, hidden: t.cursorHidden, shape: t.cursorShape, blink: t.cursorBlink}
+}
+
+// screenText returns the text of the rows, one line per row.
+func screenText(rows [][]cell) string {
+	var b strings.Builder
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		for _, c := range row {
+			b.WriteString(c.content)
+		}
+	}
+	return b.String()
+}
+
+// Print writes the rune at the cursor and moves the cursor past it.
+func (t *terminal) Print(r rune) {
+	w := runewidth.RuneWidth(r)
+	if w == 0 {
+		// combining characters join the character before the cursor.
+		x := t.x - 1
+		if t.wrapNext {
+			x = t.x
+		}
+		row := t.row(t.y)
+		if x > 0 && x < len(row) && row[x].width == 0 {
+			x--
+		}
+		if x >= 0 && x < len(row) {
+			row[x].content += string(r)
+		}
+		return
+	}
+
+	if w > cmp.Or(t.width, maxColumns-t.x) {
+		// the character doesn't fit in the terminal.
+		return
+	}
+
+	if t.width > 0 && (t.wrapNext || t.x+w > t.width) && !t.noWrap {
+		t.x = 0
+		t.lineFeed()
+	}
+	t.wrapNext = false
+	if t.width > 0 && t.x+w > t.width {
+		t.x = t.width - w
+	}
+
+	t.set(t.x, t.y, cell{content: string(r), width: w, style: t.pen, link: t.link})
+	if w > 1 {
+		t.set(t.x+1, t.y, cell{style: t.pen, link: t.link})
+	}
+	t.x += w
+	if t.width > 0 && t.x >= t.width {
+		t.x = t.width - 1
+		t.wrapNext = true
+	}
+}
+
+// Execute handles control characters.
+func (t *terminal) Execute(code byte) {
+	t.wrapNext = false
+	switch code {
+	case '\b':
+		t.x = max(t.x-1, 0)
+	case '\t':
+		t.moveTo((t.x/tabStop+1)*tabStop, t.y)
+	case '\r':
+		t.x = 0
+	case '\n', '\v', '\f':
+		t.x = 0
+		t.lineFeed()
+	}
+}
+
+// OscDispatch handles OSC sequences. Only hyperlinks change the screen.
+func (t *terminal) OscDispatch(cmd int, data []byte) {
+	if cmd != 8 {
+		return
+	}
+	// OSC 8 ; params ; uri, where an empty uri closes the link.
+	parts := strings.SplitN(string(data), ";", 3)
+	if len(parts) < 3 {
+		return
+	}
+	t.link = parts[2]
+	if !linkAllowed(t.link) {
+		// the text is kept, but it doesn't link anywhere.
+		t.link = ""
+	}
+}
+
+// linkSchemes are the schemes of the hyperlinks that stay clickable. Others,
+// like javascript:, would run in the viewer of the image.
+var linkSchemes = []string{"http", "https", "mailto", "file"}
+
+// linkAllowed reports whether the hyperlink has one of linkSchemes.
+func linkAllowed(uri string) bool {
+	u, err := url.Parse(uri)
+	return err == nil && slices.Contains(linkSchemes, strings.ToLower(u.Scheme))
+}
+
+// EscDispatch handles escape sequences.
+func (t *terminal) EscDispatch(cmd ansi.Cmd) {
+	if cmd.Intermediate() != 0 {
+		// character sets and the like do not change the screen.
+		return
+	}
+	t.wrapNext = false
+	switch cmd.Final() {
+	case '7': // DECSC
+		t.saveCursor()
+	case '8': // DECRC
+		t.restoreCursor()
+	case 'D': // IND
+		t.lineFeed()
+	case 'E': // NEL
+		t.x = 0
+		t.lineFeed()
+	case 'M': // RI
+		if t.y == t.top {
+			t.scrollDown(1)
+		} else {
+			t.y = max(t.y-1, 0)
+		}
+	case 'c': // RIS
+		*t = *newTerminal(t.width, t.height, t.colors)
+	}
+}
+
+// CsiDispatch handles control sequences.
+func (t *terminal) CsiDispatch(cmd ansi.Cmd, params ansi.Params) {
+	// n returns the count at i, which defaults to 1.
+	n := func(i int) int {
+		v, _, _ := params.Param(i, 1)
+		return clamp(v, 1, maxRows)
+	}
+	// mode returns the selective parameter at i, which defaults to 0.
+	mode := func(i int) int {
+		v, _, _ := params.Param(i, 0)
+		return clamp(v, 0, maxRows)
+	}
+
+	if cmd != 'm' {
+		t.wrapNext = false
+	}
+	switch cmd {
+	case 'm': // SGR
+		t.sgr(params)
+	case 'A': // CUU
+		t.moveTo(t.x, t.y-n(0))
+	case 'B', 'e': // CUD, VPR
+		t.moveTo(t.x, t.y+n(0))
+	case 'C', 'a': // CUF, HPR
+		t.moveTo(t.x+n(0), t.y)
+	case 'D': // CUB
+		t.moveTo(t.x-n(0), t.y)
+	case 'E': // CNL
+		t.moveTo(0, t.y+n(0))
+	case 'F': // CPL
+		t.moveTo(0, t.y-n(0))
+	case 'G', '`': // CHA, HPA
+		t.moveTo(n(0)-1, t.y)
+	case 'd': // VPA
+		t.moveTo(t.x, n(0)-1)
+	case 'H', 'f': // CUP, HVP
+		t.moveTo(n(1)-1, n(0)-1)
+	case 'J': // ED
+		t.eraseDisplay(mode(0))
+	case 'K': // EL
+		t.eraseLine(mode(0))
+	case 'X': // ECH
+		t.erase(t.y, t.x, t.x+n(0))
+	case '@': // ICH
+		t.insertCells(n(0))
+	case 'P': // DCH
+		t.deleteCells(n(0))
+	case 'L': // IL
+		t.insertLines(n(0))
+	case 'M': // DL
+		t.deleteLines(n(0))
+	case 'S': // SU
+		t.scrollUp(n(0))
+	case 'T': // SD
+		t.scrollDown(n(0))
+	case 'r': // DECSTBM
+		top, bottom := n(0)-1, mode(1)-1
+		if t.height > 0 {
+			bottom = min(bottom, t.height-1)
+		}
+		if bottom >= 0 && bottom <= top {
+			return
+		}
+		t.top, t.bottom = top, bottom
+		t.moveTo(0, 0)
+	case 's': // SCOSC
+		t.saveCursor()
+	case 'u': // SCORC
+		t.restoreCursor()
+	case ansi.Cmd(ansi.Command('?', 0, 'h')): // DECSET
+		params.ForEach(0, func(_, param int, _ bool) { t.setMode(param, true) })
+	case ansi.Cmd(ansi.Command(0, ' ', 'q')): // DECSCUSR
+		// 0 is the default cursor, odd styles blink and even ones don't.
+		style := mode(0)
+		t.cursorBlink = style%2 == 1
+		switch style {
+		case 0, 1, 2:
+			t.cursorShape = cursorBlock
+		case 3, 4:
+			t.cursorShape = cursorUnderline
+		case 5, 6:
+			t.cursorShape = cursorBar
+		}
+	case ansi.Cmd(ansi.Command('?', 0, 'l')): // DECRST
+		params.ForEach(0, func(_, param int, _ bool) { t.setMode(param, false) })
+	}
+}
+
+func (t *terminal) setMode(mode int, on bool) {
+	switch mode {
+	case 7: // DECAWM
+		t.noWrap = !on
+	case 25: // DECTCEM
+		t.cursorHidden = !on
+	case 47, 1047:
+		t.switchScreen(on)
+	case 1049:
+		if on {
+			t.saveCursor()
+			t.switchScreen(true)
+			t.rows = nil
+		} else {
+			t.switchScreen(false)
+			t.restoreCursor()
+		}
+	}
+}
+
+// switchScreen shows the alternate screen, or the main screen.
+func (t *terminal) switchScreen(alt bool) {
+	if t.alt == alt {
+		return
+	}
+	t.rows, t.other = t.other, t.rows
+	t.alt = alt
+}
+
+func (t *terminal) saveCursor() {
+	t.saved = savedCursor{x: t.x, y: t.y, pen: t.pen}
+}
+
+func (t *terminal) restoreCursor() {
+	t.x, t.y, t.pen = t.saved.x, t.saved.y, t.saved.pen
+	t.wrapNext = false
+}
+
+func (t *terminal) moveTo(x, y int) {
+	t.x, t.y = clamp(x, 0, maxColumns-1), clamp(y, 0, maxRows-1)
+	if t.width > 0 {
+		t.x = min(t.x, t.width-1)
+	}
+	if t.height > 0 {
+		t.y = min(t.y, t.height-1)
+	}
+}
+
+// lineFeed moves the cursor down a row, scrolling the scroll region when
+// the cursor is at its bottom.
+func (t *terminal) lineFeed() {
+	switch {
+	case t.y == t.bottom, t.bottom < 0 && t.y == t.height-1:
+		t.scrollUp(1)
+	case t.y == maxRows-1:
+		// the screen is as tall as it gets, so the first row scrolls off
+		// instead.
+		if t.bottom < 0 {
+			t.scrollUp(1)
+		}
+	default:
+		t.y++
+	}
+}
+
+// region returns the first and last rows of the scroll region.
+func (t *terminal) region() (int, int) {
+	switch {
+	case t.bottom >= 0:
+		return t.top, t.bottom
+	case t.height > 0:
+		return t.top, t.height - 1
+	default:
+		return t.top, max(len(t.rows)-1, t.y)
+	}
+}
+
+// fixed reports whether the scroll region has a fixed bottom, which rows
+// scrolled past are dropped from, rather than growing with the screen.
+func (t *terminal) fixed() bool {
+	return t.bottom >= 0 || t.height > 0
+}
+
+// scrollUp moves the rows of the scroll region up, adding empty rows at its
+// bottom. Rows scrolled off the top of the main screen go to the scrollback.
+func (t *terminal) scrollUp(n int) {
+	top, bottom := t.region()
+	if bottom < top {
+		return
+	}
+	t.row(bottom)
+	n = min(n, bottom-top+1)
+	if top == 0 && t.height > 0 && !t.alt {
+		t.scrollback = append(t.scrollback, t.rows[:n]...)
+		// the scrollback and the screen stay within maxRows rows.
+		if extra := len(t.scrollback) + t.height - maxRows; extra > 0 {
+			t.scrollback = slices.Delete(t.scrollback, 0, min(extra, len(t.scrollback)))
+		}
+	}
+	t.rows = slices.Delete(t.rows, top, top+n)
+	t.rows = slices.Insert(t.rows, bottom-n+1, make([][]cell, n)...)
+}
+
+// scrollDown moves the rows of the scroll region down, adding empty rows at
+// its top. Without a scroll region, the screen grows instead of dropping
+// its last rows, up to maxRows.
+func (t *terminal) scrollDown(n int) {
+	top, bottom := t.region()
+	if bottom < top {
+		return
+	}
+	t.row(bottom)
+	if t.fixed() {
+		n = min(n, bottom-top+1)
+		t.rows = slices.Delete(t.rows, bottom-n+1, bottom+1)
+	}
+	t.rows = slices.Insert(t.rows, top, make([][]cell, min(n, maxRows))...)
+	t.clip()
+}
+
+func (t *terminal) insertLines(n int) {
+	top, bottom := t.region()
+	if t.y < top || t.y > bottom {
+		return
+	}
+	t.row(bottom)
+	n = min(n, bottom-t.y+1)
+	t.rows = slices.Insert(t.rows, t.y, make([][]cell, n)...)
+	if t.fixed() {
+		t.rows = slices.Delete(t.rows, bottom+1, bottom+n+1)
+	}
+	t.clip()
+	t.x = 0
+}
+
+// clip drops the rows pushed past maxRows.
+func (t *terminal) clip() {
+	t.rows = t.rows[:min(len(t.rows), maxRows)]
+}
+
+func (t *terminal) deleteLines(n int) {
+	top, bottom := t.region()
+	if t.y < top || t.y > bottom {
+		return
+	}
+	t.row(bottom)
+	n = min(n, bottom-t.y+1)
+	t.rows = slices.Delete(t.rows, t.y, t.y+n)
+	if t.fixed() {
+		t.rows = slices.Insert(t.rows, bottom-n+1, make([][]cell, n)...)
+	}
+	t.x = 0
+}
+
+func (t *terminal) insertCells(n int) {
+	row := t.row(t.y)
+	if t.x >= len(row) {
+		return
+	}
+	blanks := make([]cell, min(n, maxColumns-t.x))
+	for i := range blanks {
+		blanks[i] = blankCell(t.pen)
+	}
+	row = slices.Insert(row, t.x, blanks...)
+	row = row[:min(len(row), cmp.Or(t.width, maxColumns))]
+	t.rows[t.y] = row
+}
+
+func (t *terminal) deleteCells(n int) {
+	row := t.row(t.y)
+	if t.x >= len(row) {
+		return
+	}
+	t.rows[t.y] = slices.Delete(row, t.x, min(t.x+n, len(row)))
+}
+
+func (t *terminal) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		t.eraseLine(0)
+		for y := t.y + 1; y < len(t.rows); y++ {
+			t.rows[y] = nil
+		}
+	case 1:
+		t.eraseLine(1)
+		for y := 0; y < t.y && y < len(t.rows); y++ {
+			t.rows[y] = nil
+		}
+	case 2:
+		t.rows = nil
+	case 3:
+		t.rows = nil
+		t.scrollback = nil
+	}
+}
+
+func (t *terminal) eraseLine(mode int) {
+	switch mode {
+	case 0:
+		t.erase(t.y, t.x, -1)
+	case 1:
+		t.erase(t.y, 0, t.x+1)
+	case 2:
+		t.erase(t.y, 0, -1)
+	}
+}
+
+// erase blanks the cells of the row from start up to end, or up to the end
+// of the row when end is -1.
+func (t *terminal) erase(y, start, end int) {
+	row := t.row(y)
+	if end < 0 {
+		if t.pen.bg == "" || t.width == 0 {
+			// the rest of the row is blank anyway.
+			if start < len(row) {
+				t.rows[y] = row[:start]
+			}
+			return
+		}
+		end = t.width
+	}
+	for x := start; x < min(end, cmp.Or(t.width, maxColumns)); x++ {
+		t.set(x, y, blankCell(t.pen))
+	}
+}
+
+// row returns the row, adding rows to the screen as needed.
+func (t *terminal) row(y int) []cell {
+	for len(t.rows) <= y {
+		t.rows = append(t.rows, nil)
+	}
+	return t.rows[y]
+}
+
+// set sets the cell at x, y, filling the row up to x with blank cells.
+func (t *terminal) set(x, y int, c cell) {
+	row := t.row(y)
+	for len(row) <= x {
+		row = append(row, blankCell(cellStyle{}))
+	}
+
+	// overwriting half of a wide character erases the other half.
+	if old := row[x]; old.width > 1 && x+1 < len(row) {
+		row[x+1] = blankCell(old.style)
+	} else if old.width == 0 && x > 0 && row[x-1].width > 1 {
+		row[x-1] = blankCell(row[x-1].style)
+	}
+
+	row[x] = c
+	t.rows[y] = row
+}
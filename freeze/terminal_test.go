@@ -0,0 +1,296 @@
+package freeze
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var charmColors = palettes["charm"].colors()
+
+func TestTerminal(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		width    int
+		expected string
+	}{
+		{"text", "Hello\nWorld", 0, "Hello\nWorld"},
+		{"trailing newline", "Hello\n", 0, "Hello\n"},
+		{"carriage return", "Loading 10%\rLoading 100%\n", 0, "Loading 100%\n"},
+		{"carriage return overwrite", "abcdef\rxy", 0, "xycdef"},
+		{"backspace", "abc\bX", 0, "abX"},
+		{"tab", "a\tb", 0, "a       b"},
+		{"erase line", "abcdef\r\x1b[Kxy", 0, "xy"},
+		{"erase line start", "abcdef\x1b[3D\x1b[1K", 0, "    ef"},
+		{"erase display", "one\ntwo\x1b[2J\x1b[Hthree", 0, "three"},
+		{"cursor position", "\x1b[3;5Hx\x1b[1;1Hy", 0, "y\n\n    x"},
+		{"cursor movement", "abc\x1b[2Dx\x1b[Bz", 0, "axc\n  z"},
+		{"column", "abcdef\x1b[3GX", 0, "abXdef"},
+		{"erase characters", "abcdef\x1b[1;2H\x1b[2X", 0, "a  def"},
+		{"insert characters", "abcdef\x1b[1;2H\x1b[2@", 0, "a  bcdef"},
+		{"delete characters", "abcdef\x1b[1;2H\x1b[2P", 0, "adef"},
+		{"insert lines", "one\ntwo\x1b[1;1H\x1b[L", 0, "\none\ntwo"},
+		{"delete lines", "one\ntwo\nthree\x1b[1;1H\x1b[M", 0, "two\nthree"},
+		{"save and restore cursor", "ab\x1b7cd\x1b8X", 0, "abXd"},
+		{"scroll region", "\x1b[1;2rone\ntwo\nthree", 0, "two\nthree"},
+		{"reverse index", "one\x1b[H\x1bMzero", 0, "zero\none"},
+		{"alternate screen", "main\x1b[?1049halt\x1b[?1049l", 0, "main"},
+		{"alternate screen shown", "main\x1b[?1049h\x1b[Halt", 0, "alt"},
+		{"wrap", "abcdef", 4, "abcd\nef"},
+		{"wrap exact", "abcd\nef", 4, "abcd\nef"},
+		{"no wrap", "\x1b[?7labcdef", 4, "abcf"},
+		{"wide", "漢字", 0, "漢字"},
+		{"wide overwrite", "漢字\rab", 0, "ab字"},
+		{"wide half overwrite", "漢字\x1b[1;2Hx", 0, " x字"},
+		{"combining", "é", 0, "é"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := screenText(emulate(tc.input, tc.width, 0, charmColors))
+			if got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestTerminalStyle(t *testing.T) {
+	rows := emulate("\x1b[31;3mred\x1b[0m plain\n\x1b[48;5;1mbg\x1b[K", 0, 0, charmColors)
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	if s := rows[0][0].style; s.fg != charmColors[1] || !s.italic {
+		t.Errorf("expected red italic text, got %+v", s)
+	}
+	if s := rows[0][4].style; s != (cellStyle{}) {
+		t.Errorf("expected plain text after a reset, got %+v", s)
+	}
+	if s := rows[1][0].style; s.bg != charmColors[1] {
+		t.Errorf("expected a background, got %+v", s)
+	}
+}
+
+func TestTerminalSGR(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected cellStyle
+	}{
+		{"foreground", "\x1b[31mx", cellStyle{fg: charmColors[1]}},
+		{"bright foreground", "\x1b[91mx", cellStyle{fg: charmColors[9]}},
+		{"background", "\x1b[42mx", cellStyle{bg: charmColors[2]}},
+		{"bright background", "\x1b[104mx", cellStyle{bg: charmColors[12]}},
+		{"256 colors", "\x1b[38;5;196;48;5;21mx", cellStyle{fg: charmColors[196], bg: charmColors[21]}},
+		{"true color", "\x1b[38;2;1;2;3;48;2;4;5;6mx", cellStyle{fg: "#010203", bg: "#040506"}},
+		{"combined", "\x1b[1;3;4;9;31;42mx", cellStyle{fg: charmColors[1], bg: charmColors[2], bold: true, italic: true, underline: underlineSingle, strike: true}},
+		{"separate sequences", "\x1b[3m\x1b[31m\x1b[4mx", cellStyle{fg: charmColors[1], italic: true, underline: underlineSingle}},
+		{"default foreground", "\x1b[31;42m\x1b[39mx", cellStyle{bg: charmColors[2]}},
+		{"default background", "\x1b[31;42m\x1b[49mx", cellStyle{fg: charmColors[1]}},
+		{"normal intensity", "\x1b[1;3m\x1b[22mx", cellStyle{italic: true}},
+		{"not italic", "\x1b[1;3m\x1b[23mx", cellStyle{bold: true}},
+		{"not underlined", "\x1b[4;9m\x1b[24mx", cellStyle{strike: true}},
+		{"not crossed out", "\x1b[4;9m\x1b[29mx", cellStyle{underline: underlineSingle}},
+		{"faint", "\x1b[2mx", cellStyle{faint: true}},
+		{"blink", "\x1b[5mx", cellStyle{blink: true}},
+		{"reverse", "\x1b[7mx", cellStyle{reverse: true}},
+		{"conceal", "\x1b[8mx", cellStyle{conceal: true}},
+		{"not bold or faint", "\x1b[1;2m\x1b[22mx", cellStyle{}},
+		{"not blinking", "\x1b[5;7m\x1b[25mx", cellStyle{reverse: true}},
+		{"not reversed", "\x1b[7;8m\x1b[27mx", cellStyle{conceal: true}},
+		{"revealed", "\x1b[7;8m\x1b[28mx", cellStyle{reverse: true}},
+		{"curly underline", "\x1b[4:3mx", cellStyle{underline: underlineCurly}},
+		{"dashed underline", "\x1b[4:5mx", cellStyle{underline: underlineDashed}},
+		{"no underline style", "\x1b[4m\x1b[4:0mx", cellStyle{}},
+		{"double underline", "\x1b[21mx", cellStyle{underline: underlineDouble}},
+		{"not curly underlined", "\x1b[4:3m\x1b[24mx", cellStyle{}},
+		{"underline color", "\x1b[4;58;5;1mx", cellStyle{underline: underlineSingle, underlineColor: charmColors[1]}},
+		{"underline true color", "\x1b[4:2;58:2::1:2:3mx", cellStyle{underline: underlineDouble, underlineColor: "#010203"}},
+		{"default underline color", "\x1b[4;58;5;1m\x1b[59mx", cellStyle{underline: underlineSingle}},
+		{"unknown sub-parameters", "\x1b[1:3;31mx", cellStyle{fg: charmColors[1], bold: true}},
+		{"colon 256 colors", "\x1b[38:5:196mx", cellStyle{fg: charmColors[196]}},
+		{"colon true color", "\x1b[48:2:1:2:3mx", cellStyle{bg: "#010203"}},
+		{"colon true color with color space", "\x1b[38:2:0:1:2:3;1mx", cellStyle{fg: "#010203", bold: true}},
+		{"out of range color", "\x1b[38;5;300;1mx", cellStyle{bold: true}},
+		{"clamped true color", "\x1b[38;2;300;2;3mx", cellStyle{fg: "#ff0203"}},
+		{"truncated 256 colors", "\x1b[1;38;5mx", cellStyle{bold: true}},
+		{"truncated true color", "\x1b[1;48;2;1;2mx", cellStyle{bold: true}},
+		{"unknown color space", "\x1b[38;7;1mx", cellStyle{bold: true}},
+		{"missing color space", "\x1b[1;38mx", cellStyle{bold: true}},
+		{"unknown code", "\x1b[1;1000mx", cellStyle{bold: true}},
+		{"reset", "\x1b[1;31;42m\x1b[0mx", cellStyle{}},
+		{"empty reset", "\x1b[1;31;42m\x1b[mx", cellStyle{}},
+		{"across lines", "\x1b[31mone\nx", cellStyle{fg: charmColors[1]}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rows := emulate(tc.input, 0, 0, charmColors)
+			row := rows[len(rows)-1]
+			if got := row[len(row)-1].style; got != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestTerminalLinks(t *testing.T) {
+	rows := emulate("a\x1b]8;id=1;https://charm.sh\x1b\\bc\x1b]8;;\x07d", 0, 0, charmColors)
+	var links []string
+	for _, c := range rows[0] {
+		links = append(links, c.link)
+	}
+	expected := []string{"", "https://charm.sh", "https://charm.sh", ""}
+	if !slices.Equal(links, expected) {
+		t.Errorf("expected links %q, got %q", expected, links)
+	}
+}
+
+func TestTerminalCursor(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  cursor
+	}{
+		{"end of output", "ab\ncd", cursor{x: 2, y: 1}},
+		{"moved", "abcd\x1b[2D", cursor{x: 2}},
+		{"hidden", "ab\x1b[?25l", cursor{x: 2, hidden: true}},
+		{"shown again", "ab\x1b[?25l\x1b[?25h", cursor{x: 2}},
+		{"blinking underline", "ab\x1b[3 q", cursor{x: 2, shape: cursorUnderline, blink: true}},
+		{"steady bar", "ab\x1b[6 q", cursor{x: 2, shape: cursorBar}},
+		{"default", "ab\x1b[6 q\x1b[0 q", cursor{x: 2}},
+		{"reset", "ab\x1b[?25l\x1b[6 q\x1bc", cursor{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := runTerminal(tc.input, 0, 0, charmColors).cursor(); got != tc.want {
+				t.Errorf("expected cursor %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestTerminalHeight(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		height   int
+		expected string
+	}{
+		{"grows without a height", "1\n2\n3\n4\x1b[Hx", 0, "x\n2\n3\n4"},
+		{"scrollback", "1\n2\n3\n4", 3, "1\n2\n3\n4"},
+		{"home after scrolling", "1\n2\n3\n4\x1b[Hx", 3, "1\nx\n3\n4"},
+		{"cursor on the screen", "a\x1b[9999Bb", 3, "a\n\n b"},
+		{"scroll up", "1\n2\x1b[S", 2, "1\n2\n"},
+		{"scroll down", "1\n2\x1b[T", 2, "\n1"},
+		{"insert lines", "1\n2\x1b[H\x1b[L", 2, "\n1"},
+		{"scroll region", "1\n2\n3\x1b[2;3r\x1b[3;1H\nx", 3, "1\n3\nx"},
+		{"alternate screen", "1\n2\n3\x1b[?1049h\x1b[Halt", 2, "alt"},
+		{"alternate screen left", "1\n2\n3\x1b[?1049h\x1b[Halt\x1b[?1049l", 2, "1\n2\n3"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := screenText(emulate(tc.input, 0, tc.height, charmColors))
+			if got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+
+	// the cursor is on the rows of the screen, below the scrollback.
+	if c := runTerminal("1\n2\n3", 0, 2, charmColors).cursor(); c.x != 1 || c.y != 2 {
+		t.Errorf("expected the cursor at 1,2, got %d,%d", c.x, c.y)
+	}
+}
+
+func TestTerminalMaxRows(t *testing.T) {
+	for _, input := range []string{
+		strings.Repeat("x\x1b[9999L", 200),
+		strings.Repeat("\x1b[9999T", 200),
+		strings.Repeat("\x1b[9999B\x1b[9999L", 3),
+		"first\n" + strings.Repeat("\n", maxRows) + "last",
+	} {
+		for _, height := range []int{0, 24} {
+			term := runTerminal(input, 0, height, charmColors)
+			if n := len(term.scrollback) + len(term.rows); n > maxRows {
+				t.Errorf("%.20q: expected at most %d rows, got %d", input, maxRows, n)
+			}
+		}
+	}
+
+	// the first rows scroll off once the screen is full.
+	rows := emulate("first\n"+strings.Repeat("\n", maxRows)+"last", 0, 0, charmColors)
+	if got := screenText(rows[len(rows)-1:]); got != "last" {
+		t.Errorf("expected the last row to be kept, got %q", got)
+	}
+	if got := screenText(rows[:1]); got == "first" {
+		t.Error("expected the first row to scroll off")
+	}
+}
+
+func FuzzEmulate(f *testing.F) {
+	for _, seed := range []string{
+		"Hello\nWorld",
+		"\x1b[1;31mred\x1b[0m\r\n",
+		"\x1b[38:2::255:0:0;48;5;21mx\x1b[m",
+		"\x1b[2J\x1b[10;10H\x1b[K\x1b[3@\x1b[2P\x1b[5X",
+		"\x1b[2;5r\x1bM\x1b[3L\x1b[2M\x1b[S\x1b[T",
+		"\x1b[?1049h\x1b[Halt\x1b[?1049l\x1b7\x1b8",
+		"漢字\té\b\x1b]8;;https://charm.sh\x07link\x1b]8;;\x07",
+		"\x1b[999999999B\x1b[999999999C\x1b[999999999@x",
+	} {
+		f.Add(seed, uint8(0), uint8(0))
+		f.Add(seed, uint8(4), uint8(3))
+	}
+
+	f.Fuzz(func(t *testing.T, input string, width, height uint8) {
+		term := runTerminal(input, int(width), int(height), charmColors)
+		if len(term.scrollback)+len(term.rows) > maxRows || len(term.other) > maxRows {
+			t.Errorf("expected the screens to stay within %d rows, got %d and %d", maxRows, len(term.scrollback)+len(term.rows), len(term.other))
+		}
+		if height > 0 && (len(term.rows) > int(height) || len(term.other) > int(height)) {
+			t.Errorf("expected the screens to stay within %d rows, got %d and %d", height, len(term.rows), len(term.other))
+		}
+		rows := term.screen()
+		for y, row := range rows {
+			if limit := cmp.Or(int(width), maxColumns); len(row) > limit {
+				t.Errorf("expected row %d to fit in %d columns, got %d", y, limit, len(row))
+			}
+			for _, c := range row {
+				for _, color := range []string{c.style.fg, c.style.bg, c.style.underlineColor} {
+					if _, err := parseHexColor(color); color != "" && err != nil {
+						t.Errorf("expected a valid color on row %d, got %q", y, color)
+					}
+				}
+			}
+		}
+	})
+}
+
+func FuzzSGR(f *testing.F) {
+	for _, seed := range []string{"", "0", "1;31", "38;5;196", "38;2;1;2;3", "38:2::1:2:3", "4:3;58:5:1", "38;5", "48:2", "1:2:3:4"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, params string) {
+		rows := emulate("\x1b["+params+"mx", 0, 0, charmColors)
+		if len(rows) == 0 || len(rows[len(rows)-1]) == 0 {
+			// the parameters ended the sequence early.
+			return
+		}
+		row := rows[len(rows)-1]
+		style := row[len(row)-1].style
+		for _, color := range []string{style.fg, style.bg, style.underlineColor} {
+			if _, err := parseHexColor(color); color != "" && err != nil {
+				t.Errorf("expected a valid color, got %q", color)
+			}
+		}
+		if style.underline > underlineDashed {
+			t.Errorf("expected a known underline style, got %d", style.underline)
+		}
+	})
+}
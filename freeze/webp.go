@@ -0,0 +1,113 @@
+package freeze
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/HugoSmits86/nativewebp"
+)
+
+// maxWebPSize is the largest width and height of a WebP image.
+const maxWebPSize = 1 << 14
+
+// encodeWebP encodes the image as a WebP, which is always lossless.
+//
+// Given a quality, the image is first reduced to a palette whose size scales
+// with it, which is what keeps the files small. Without one, or when
+// lossless, every pixel is kept as is.
+func encodeWebP(img image.Image, quality int, lossless bool) ([]byte, error) {
+	if b := img.Bounds(); b.Dx() > maxWebPSize || b.Dy() > maxWebPSize {
+		return nil, fmt.Errorf("webp images can't be larger than %[1]dx%[1]d, got %dx%d", maxWebPSize, b.Dx(), b.Dy())
+	}
+	if quality > 0 && !lossless {
+		img = quantize(img, paletteSize(quality))
+	}
+
+	var buf bytes.Buffer
+	if err := nativewebp.Encode(&buf, img, nil); err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+	return buf.Bytes(), nil
+}
+
+// paletteSize maps a 1-100 quality to a number of palette colors.
+func paletteSize(quality int) int {
+	return clamp(quality, 1, 100) * 256 / 100
+}
+
+// webpEncoder encodes an animated WebP, compressing the frames like
+// encodeWebP.
+type webpEncoder struct {
+	quality       int
+	lossless      bool
+	width, height int
+	frames        bytes.Buffer
+}
+
+func (e *webpEncoder) addFrame(img *image.NRGBA, changed image.Rectangle, delay time.Duration) error {
+	if e.width == 0 {
+		e.width, e.height = img.Bounds().Dx(), img.Bounds().Dy()
+	}
+
+	// frame offsets are stored divided by two.
+	changed.Min.X &^= 1
+	changed.Min.Y &^= 1
+
+	b, err := encodeWebP(img.SubImage(changed), e.quality, e.lossless)
+	if err != nil {
+		return err
+	}
+	// skip the RIFF header of the still image, keeping its VP8L chunk.
+	bitstream := b[12:]
+
+	header := make([]byte, 16)
+	putUint24(header[0:], changed.Min.X/2)
+	putUint24(header[3:], changed.Min.Y/2)
+	putUint24(header[6:], changed.Dx()-1)
+	putUint24(header[9:], changed.Dy()-1)
+	putUint24(header[12:], clamp(int(delay/time.Millisecond), 0, 0xffffff))
+	// do not blend, the frame replaces the pixels it covers.
+	header[15] = 0x02
+	writeWebPChunk(&e.frames, "ANMF", append(header, bitstream...))
+	return nil
+}
+
+func (e *webpEncoder) encode() ([]byte, error) {
+	var chunks bytes.Buffer
+
+	// extended format with alpha and animation.
+	vp8x := make([]byte, 10)
+	vp8x[0] = 0x10 | 0x02
+	putUint24(vp8x[4:], e.width-1)
+	putUint24(vp8x[7:], e.height-1)
+	writeWebPChunk(&chunks, "VP8X", vp8x)
+
+	// transparent background, looping forever.
+	writeWebPChunk(&chunks, "ANIM", make([]byte, 6))
+	chunks.Write(e.frames.Bytes())
+
+	var out bytes.Buffer
+	out.WriteString("RIFF")
+	_ = binary.Write(&out, binary.LittleEndian, uint32(4+chunks.Len())) //nolint: gosec
+	out.WriteString("WEBP")
+	out.Write(chunks.Bytes())
+	return out.Bytes(), nil
+}
+
+func writeWebPChunk(buf *bytes.Buffer, name string, data []byte) {
+	buf.WriteString(name)
+	_ = binary.Write(buf, binary.LittleEndian, uint32(len(data))) //nolint: gosec
+	buf.Write(data)
+	if len(data)%2 != 0 {
+		buf.WriteByte(0)
+	}
+}
+
+func putUint24(b []byte, v int) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
@@ -0,0 +1,90 @@
+package freeze
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func TestEncodeWebP(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for y := range 20 {
+		for x := range 40 {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 6), G: uint8(y * 12), B: 0x17, A: 0xff})
+		}
+	}
+
+	tests := []struct {
+		quality  int
+		lossless bool
+		keep     bool
+	}{
+		{quality: 0, keep: true},
+		{quality: 50, lossless: true, keep: true},
+		{quality: 50},
+	}
+	for _, tc := range tests {
+		b, err := encodeWebP(img, tc.quality, tc.lossless)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := webp.Decode(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Bounds() != img.Bounds() {
+			t.Fatalf("expected bounds %v, got %v", img.Bounds(), got.Bounds())
+		}
+		colors := map[color.Color]bool{}
+		for y := range 20 {
+			for x := range 40 {
+				colors[got.At(x, y)] = true
+			}
+		}
+		if tc.keep && (len(colors) != 40*20 || got.At(39, 19) != img.At(39, 19)) {
+			t.Fatalf("quality %d: expected every pixel to be kept, got %d colors", tc.quality, len(colors))
+		}
+		if !tc.keep && len(colors) > paletteSize(tc.quality) {
+			t.Fatalf("quality %d: expected at most %d colors, got %d", tc.quality, paletteSize(tc.quality), len(colors))
+		}
+	}
+}
+
+func TestEncodeWebPTooLarge(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 10, maxWebPSize+1))
+	if _, err := encodeWebP(img, 0, false); err == nil {
+		t.Fatal("expected an error for an image taller than WebP allows")
+	}
+}
+
+func TestQuantize(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 256, 1))
+	for x := range 256 {
+		img.Set(x, 0, color.NRGBA{R: uint8(x), A: 0xff})
+	}
+	got := quantize(img, 16)
+	if len(got.Palette) != 16 {
+		t.Fatalf("expected 16 colors, got %d", len(got.Palette))
+	}
+}
+
+func TestQuantizeReproducible(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for y := range 64 {
+		for x := range 64 {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 4), G: uint8(y * 4), B: uint8(x ^ y), A: 0xff})
+		}
+	}
+
+	want := quantize(img, 32)
+	for range 5 {
+		got := quantize(img, 32)
+		if !slices.Equal(got.Palette, want.Palette) || !bytes.Equal(got.Pix, want.Pix) {
+			t.Fatal("expected the same image to be quantized the same way every time")
+		}
+	}
+}
@@ -65,6 +65,54 @@ func TestFreezeOutput(t *testing.T) {
 	}
 }
 
+func TestFreezeOutputRendererFallback(t *testing.T) {
+	output := "artichoke-test.png"
+	defer os.Remove(output)
+
+	var out, stderr bytes.Buffer
+	cmd := exec.Command(binary, "test/input/artichoke.hs", "-o", output, "--renderer", "rsvg,native")
+	// rsvg-convert can't be found, so native draws the image.
+	cmd.Env = append(os.Environ(), "PATH="+t.TempDir())
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stderr.String(), "rsvg failed, rendered with native") {
+		t.Errorf("expected a warning about the fallback, got %q", stderr.String())
+	}
+	if !strings.HasSuffix(strings.TrimSpace(out.String()), output) {
+		t.Errorf("expected the output file to be written as usual, got %q", out.String())
+	}
+}
+
+func TestFreezeOutputStdout(t *testing.T) {
+	tests := []struct {
+		args   []string
+		prefix string
+	}{
+		{[]string{"-o", "-"}, "<?xml"},
+		{[]string{"-o", "-", "--format", "png", "--renderer", "native"}, "\x89PNG"},
+		{[]string{"--format", "png", "--renderer", "native"}, "\x89PNG"},
+	}
+
+	for _, tc := range tests {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			out := bytes.Buffer{}
+			cmd := exec.Command(binary, append([]string{"test/input/artichoke.hs"}, tc.args...)...)
+			cmd.Stdout = &out
+			err := cmd.Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(out.String(), tc.prefix) {
+				t.Fatalf("expected output starting with %q, got %.16q", tc.prefix, out.String())
+			}
+		})
+	}
+}
+
 func TestFreezeHelp(t *testing.T) {
 	out := bytes.Buffer{}
 	cmd := exec.Command(binary)
@@ -117,6 +165,27 @@ func TestFreezeErrorFileMissing(t *testing.T) {
 	}
 }
 
+func TestFreezeErrorTranscriptFlags(t *testing.T) {
+	for _, flags := range [][]string{
+		{"--execute", "echo hi"},
+		{"--animate"},
+	} {
+		t.Run(flags[0], func(t *testing.T) {
+			out := bytes.Buffer{}
+			cmd := exec.Command(binary, append([]string{"--transcript", "test/input/steps.sh"}, flags...)...)
+			cmd.Stdout = &out
+			if err := cmd.Run(); err == nil {
+				t.Fatal("expected error")
+			}
+			for _, c := range []string{"Invalid Usage", "--transcript", flags[0]} {
+				if !strings.Contains(out.String(), c) {
+					t.Errorf("expected %q to contain %q", out.String(), c)
+				}
+			}
+		})
+	}
+}
+
 func TestFreezeConfigurations(t *testing.T) {
 	tests := []struct {
 		input  string
@@ -142,6 +211,57 @@ func TestFreezeConfigurations(t *testing.T) {
 			flags:  []string{"--execute", `echo "Hello, world!"`},
 			output: "execute",
 		},
+		{
+			flags: []string{
+				"--execute", `sh -c 'stty size; echo $TERM $COLORTERM $FREEZE; head -n 1 artichoke.hs'`,
+				"--execute.cols", "40", "--execute.rows", "10", "--execute.env", "FREEZE=frozen,thawed",
+				"--execute.dir", "test/input", "--execute.clean-env",
+			},
+			output: "execute-environment",
+		},
+		{
+			flags:  []string{"--execute", "printf '%s' aaaaaaaaaabbbbbbbbbbccccccccccdddddddddd", "--execute.cols", "10"},
+			output: "execute-cols",
+		},
+		{
+			flags:  []string{"--execute", `printf '1\n2\n3\n4\033[Hx'`, "--execute.rows", "3"},
+			output: "execute-rows",
+		},
+		{
+			flags: []string{
+				"--execute", `sh -c 'printf "Name? "; read name; echo "Hi $name"; sleep 5'`,
+				"--execute.script", "test/input/greet.script",
+			},
+			output: "execute-script",
+		},
+		{
+			flags: []string{
+				"--execute", `sh -c 'echo building; sleep 0.2; echo "error: missing semicolon" >&2; sleep 0.2; echo done; exit 2'`,
+				"--execute.stderr", "--execute.stderr-marker", "! ", "--execute.exit-status",
+			},
+			output: "execute-stderr",
+		},
+		{
+			flags: []string{
+				"--execute", "head -n 2 artichoke.hs", "--execute.dir", "test/input",
+				"--execute.prompt", "{dir} $ ", "--execute.prompt-color", "#8056ff",
+			},
+			output: "execute-prompt",
+		},
+		{
+			flags:  []string{"--transcript", "test/input/steps.sh", "--execute.dir", "test", "--execute.prompt", "{dir} $ ", "--execute.exit-status"},
+			output: "transcript",
+		},
+		{
+			input:  "test/input/cursor.ansi",
+			flags:  []string{"--cursor", "block"},
+			output: "cursor",
+		},
+		{
+			input:  "test/input/demo.cast",
+			flags:  []string{"--at", "1s"},
+			output: "cast",
+		},
 		{
 			input:  "test/input/bubbletea.model",
 			flags:  []string{"--language", "go", "--height", "800", "--width", "750", "--config", "full", "--window=false", "--show-line-numbers"},
@@ -257,6 +377,11 @@ func TestFreezeConfigurations(t *testing.T) {
 			flags:  []string{},
 			output: "glow",
 		},
+		{
+			input:  "test/input/progress.ansi",
+			flags:  []string{},
+			output: "progress",
+		},
 		{
 			input:  "test/input/tab.go",
 			flags:  []string{},
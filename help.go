@@ -9,7 +9,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-const space = 18
+const space = 23
 
 var highlighter = regexp.MustCompile("{{(.+?)}}")
 
@@ -10,11 +10,13 @@ import (
 	"github.com/alecthomas/chroma/v2/styles"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/charmbracelet/freeze/freeze"
 )
 
 var green = lipgloss.Color("#03BF87")
 
-func runForm(config *Config) (*Config, error) {
+func runForm(config *freeze.Config) (*freeze.Config, error) {
 	var (
 		padding      = strings.Trim(fmt.Sprintf("%v", config.Padding), "[]")
 		margin       = strings.Trim(fmt.Sprintf("%v", config.Margin), "[]")
@@ -262,7 +264,7 @@ func parsePadding(v string) []float64 {
 		pi, _ := strconv.ParseFloat(p, 64) // already validated
 		values = append(values, pi)
 	}
-	return expandPadding(values, 1)
+	return freeze.ExpandPadding(values, 1)
 }
 
 var parseMargin = parsePadding
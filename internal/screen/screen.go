@@ -0,0 +1,8 @@
+// Package screen lets the freeze command read the screen of the virtual
+// terminal of the freeze package, which isn't part of its API.
+package screen
+
+// Text returns the text a terminal with the given number of columns and
+// rows shows after the input, along with the rows it scrolled off. Either
+// is unlimited when 0. The freeze package sets it.
+var Text func(input string, width, height int) string
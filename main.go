@@ -1,33 +1,20 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"image"
 	"os"
-	"path/filepath"
 	"runtime/debug"
 	"strings"
 
-	"github.com/alecthomas/chroma/v2"
-	formatter "github.com/alecthomas/chroma/v2/formatters/svg"
-	"github.com/alecthomas/chroma/v2/lexers"
-	"github.com/alecthomas/chroma/v2/styles"
 	"github.com/alecthomas/kong"
-	"github.com/beevik/etree"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/charmbracelet/log"
-	"github.com/charmbracelet/x/ansi"
-	"github.com/charmbracelet/x/cellbuf"
 	"github.com/mattn/go-isatty"
 
+	"github.com/charmbracelet/freeze/freeze"
 	in "github.com/charmbracelet/freeze/input"
-	"github.com/charmbracelet/freeze/svg"
-)
-
-const (
-	defaultFontSize   = 14.0
-	defaultLineHeight = 1.2
 )
 
 var (
@@ -46,9 +33,7 @@ func main() {
 	var (
 		input  string
 		err    error
-		lexer  chroma.Lexer
-		config Config
-		scale  float64
+		config freeze.Config
 	)
 
 	k, err := kong.New(&config, kong.Help(helpPrinter))
@@ -77,9 +62,31 @@ func main() {
 		os.Exit(0)
 	}
 
-	// Copy the pty output to buffer
-	if config.Execute != "" {
-		input, err = executeCommand(config)
+	switch {
+	case config.Transcript != "" && config.Execute != "":
+		printErrorFatal("Invalid Usage", errors.New("--transcript runs its own commands, it can't be used with --execute"))
+	case config.Transcript != "" && config.Animate:
+		printErrorFatal("Invalid Usage", errors.New("--transcript can't be used with --animate, record a command with --execute instead"))
+	}
+
+	// animations are made of the frames of a command or a recording.
+	if config.Animate && config.Execute == "" && !isCast(config.Input) {
+		printErrorFatal("Invalid Usage", errors.New("--animate records a command, use it with --execute or a .cast recording"))
+	}
+
+	var frames []freeze.Frame
+	if config.Execute != "" || config.Transcript != "" {
+		switch {
+		case config.Transcript != "":
+			input, err = runTranscript(config)
+		case config.Animate:
+			frames, err = recordCommand(config)
+			if len(frames) > 0 {
+				input = frames[len(frames)-1].Input
+			}
+		default:
+			input, err = executeCommand(config)
+		}
 		if err != nil {
 			if input != "" {
 				err = fmt.Errorf("%w\n%s", err, input)
@@ -114,6 +121,14 @@ func main() {
 	if err != nil {
 		printErrorFatal("Invalid Usage", err)
 	}
+	if (config.Execute != "" || config.Transcript != "") && config.ExecuteCols > 0 && config.Wrap == 0 {
+		// wrap the output where the terminal of the command does.
+		config.Wrap = config.ExecuteCols
+	}
+	if config.Execute != "" {
+		// scroll the output within the rows of the terminal of the command.
+		_, config.Rows = terminalSize(config)
+	}
 
 	if config.Interactive {
 		cfg, interactiveErr := runForm(&config)
@@ -126,327 +141,125 @@ func main() {
 		}
 	}
 
-	autoHeight := config.Height == 0
-	autoWidth := config.Width == 0
-
+	// write to stdout when asked to with "-o -", or when piping with an
+	// explicit format and no output file.
+	toStdout := config.Output == "-" ||
+		(config.Output == "" && config.Format != "" && !isatty.IsTerminal(os.Stdout.Fd()))
+	// only preview the image, unless an output was given too.
+	previewOnly := config.Preview && config.Output == "" && config.Format == ""
 	if config.Output == "" {
 		config.Output = defaultOutputFilename
+		if config.Animate {
+			config.Output = "freeze.gif"
+		}
+		if config.Format != "" {
+			config.Output = "freeze." + config.OutputFormat()
+		}
 	}
 
-	scale = 1
-	if autoHeight && autoWidth && strings.HasSuffix(config.Output, ".png") {
-		scale = 4
-	}
-
-	config.Margin = expandMargin(config.Margin, scale)
-	config.Padding = expandPadding(config.Padding, scale)
-
 	if config.Input == "" && !in.IsPipe(os.Stdin) && len(ctx.Args) <= 0 {
 		_ = helpPrinter(kong.HelpOptions{}, ctx)
 		os.Exit(0)
 	}
 
 	if config.Input == "-" || in.IsPipe(os.Stdin) {
+		config.Input = "-"
 		input, err = in.ReadInput(os.Stdin)
-		lexer = lexers.Analyse(input)
-	} else if config.Execute != "" {
+	} else if config.Execute != "" || config.Transcript != "" {
 		config.Language = "ansi"
 	} else {
 		input, err = in.ReadFile(config.Input)
 		if err != nil {
 			printErrorFatal("File not found", err)
 		}
-		lexer = lexers.Get(config.Input)
-	}
-
-	if config.Language != "" {
-		lexer = lexers.Get(config.Language)
-	}
-
-	// adjust for 1-indexing
-	for i := range config.Lines {
-		config.Lines[i]--
-	}
-
-	strippedInput := ansi.Strip(input)
-	isAnsi := strings.ToLower(config.Language) == "ansi" || strippedInput != input
-	strippedInput = cut(strippedInput, config.Lines)
-
-	// wrap to character limit.
-	if config.Wrap > 0 {
-		strippedInput = cellbuf.Wrap(strippedInput, config.Wrap, "")
-		input = cellbuf.Wrap(input, config.Wrap, "")
+		if isCast(config.Input) {
+			input, frames, err = replayCast(&config, input)
+			if err != nil {
+				printErrorFatal("Invalid recording", err)
+			}
+		}
 	}
-
-	if !isAnsi && lexer == nil {
-		printErrorFatal("Language Unknown", errors.New("specify a language with the --language flag"))
+	if input == "" && err != nil {
+		printErrorFatal("No input", err)
 	}
 
-	input = cut(input, config.Lines)
-	if input == "" {
+	if config.Preview {
+		err = preview(os.Stdout, config, input)
 		if err != nil {
-			printErrorFatal("No input", err)
-		} else {
-			printErrorFatal("No input", errors.New("check --lines is within bounds"))
+			printErrorFatal("Unable to preview", err)
 		}
-	}
-
-	s, ok := styles.Registry[strings.ToLower(config.Theme)]
-	if s == nil || !ok {
-		s = charmStyle
-	}
-	if !s.Has(chroma.Background) {
-		s, err = s.Builder().Add(chroma.Background, "bg:"+config.Background).Build()
-		if err != nil {
-			printErrorFatal("Could not add background", err)
+		if previewOnly {
+			return
 		}
 	}
 
-	// Create a token iterator.
-	var it chroma.Iterator
-	if isAnsi {
-		// For ANSI output, we'll inject our own SVG. For now, let's just strip the ANSI
-		// codes and print the text to properly size the input.
-		it = chroma.Literator(chroma.Token{Type: chroma.Text, Value: strippedInput})
-	} else {
-		it, err = chroma.Coalesce(lexer).Tokenise(nil, input)
-		if err != nil {
-			printErrorFatal("Could not lex file", err)
+	var b []byte
+	if config.Animate {
+		b, err = freeze.EncodeAnimation(context.Background(), config, frames)
+		switch {
+		case errors.Is(err, freeze.ErrUnknownFormat):
+			printErrorFatal("Unknown format", errors.New("animations can be gif, png, or webp"))
+		case err != nil:
+			printErrorFatal("Unable to create animation", err)
 		}
-	}
-
-	// Format the code to an SVG.
-	options, err := fontOptions(&config)
-	if err != nil {
-		printErrorFatal("Invalid font options", err)
-	}
-
-	f := formatter.New(options...)
-	if err != nil {
-		printErrorFatal("Malformed text", err)
-	}
-
-	buf := &bytes.Buffer{}
-	err = f.Format(buf, s, it)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// Parse SVG (XML document)
-	doc := etree.NewDocument()
-	_, err = doc.ReadFrom(buf)
-	if err != nil {
-		printErrorFatal("Bad SVG", err)
-	}
-
-	elements := doc.ChildElements()
-	if len(elements) < 1 {
-		printErrorFatal("Bad Output", nil)
-	}
-
-	image := elements[0]
-
-	hPadding := config.Padding[left] + config.Padding[right]
-	hMargin := config.Margin[left] + config.Margin[right]
-	vMargin := config.Margin[top] + config.Margin[bottom]
-	vPadding := config.Padding[top] + config.Padding[bottom]
-
-	terminal := image.SelectElement("rect")
-
-	w, h := svg.GetDimensions(image)
-
-	imageWidth := float64(w)
-	imageHeight := float64(h)
-
-	imageWidth *= scale
-	imageHeight *= scale
-
-	// chroma automatically calculates the height based on a font size of 14
-	// and a line height of 1.2
-	imageHeight *= (config.Font.Size / defaultFontSize)
-	imageHeight *= (config.LineHeight / defaultLineHeight)
-
-	terminalWidth := imageWidth
-	terminalHeight := imageHeight
-
-	if !autoWidth {
-		imageWidth = config.Width
-		terminalWidth = config.Width - hMargin
 	} else {
-		imageWidth += hMargin + hPadding
-		terminalWidth += hPadding
+		b = render(config, input)
 	}
 
-	if !autoHeight {
-		imageHeight = config.Height
-		terminalHeight = config.Height - vMargin
-	} else {
-		imageHeight += vMargin + vPadding
-		terminalHeight += vPadding
-	}
-
-	if config.Window {
-		windowControls := svg.NewWindowControls(5.5*float64(scale), 19.0*scale, 12.0*scale)
-		svg.Move(windowControls, float64(config.Margin[left]), float64(config.Margin[top]))
-		image.AddChild(windowControls)
-		config.Padding[top] += (15 * scale)
-	}
-
-	if config.Border.Radius > 0 {
-		svg.AddCornerRadius(terminal, config.Border.Radius*scale)
-	}
-
-	if config.Shadow.Blur > 0 || config.Shadow.X > 0 || config.Shadow.Y > 0 {
-		id := "shadow"
-		svg.AddShadow(image, id, config.Shadow.X*scale, config.Shadow.Y*scale, config.Shadow.Blur*scale)
-		terminal.CreateAttr("filter", fmt.Sprintf("url(#%s)", id))
-	}
-
-	textGroup := image.SelectElement("g")
-	textGroup.CreateAttr("font-size", fmt.Sprintf("%.2fpx", config.Font.Size*float64(scale)))
-	textGroup.CreateAttr("clip-path", "url(#terminalMask)")
-	text := textGroup.SelectElements("text")
-
-	d := dispatcher{lines: text, svg: textGroup, config: &config, scale: scale}
-
-	offsetLine := 0
-	if len(config.Lines) > 0 {
-		offsetLine = config.Lines[0]
-	}
-
-	config.LineHeight *= float64(scale)
-
-	for i, line := range text {
-		if isAnsi {
-			line.SetText("")
-		}
-		// Offset the text by padding...
-		// (x, y) -> (x+p, y+p)
-		if config.ShowLineNumbers {
-			ln := etree.NewElement("tspan")
-			ln.CreateAttr("xml:space", "preserve")
-			ln.CreateAttr("fill", s.Get(chroma.LineNumbers).Colour.String())
-			ln.SetText(fmt.Sprintf("%3d  ", i+1+offsetLine))
-			line.InsertChildAt(0, ln)
-		}
-		x := float64(config.Padding[left] + config.Margin[left])
-		y := (float64(i+1))*(config.Font.Size*config.LineHeight) + float64(config.Padding[top]) + float64(config.Margin[top])
-
-		svg.Move(line, x, y)
-
-		// We are passed visible lines, remove the rest.
-		if y > float64(imageHeight-config.Margin[bottom]-config.Padding[bottom]) {
-			textGroup.RemoveChild(line)
-		}
-	}
-
-	if autoWidth {
-		tabWidth := 4
-		if isAnsi {
-			tabWidth = 6
+	if toStdout {
+		_, err = os.Stdout.Write(b)
+		if err != nil {
+			printErrorFatal("Unable to write output", err)
 		}
-		longestLine := lipgloss.Width(strings.ReplaceAll(strippedInput, "\t", strings.Repeat(" ", tabWidth)))
-		terminalWidth = float64(longestLine+1) * (config.Font.Size / fontHeightToWidthRatio)
-		terminalWidth *= scale
-		terminalWidth += hPadding
-		imageWidth = terminalWidth + hMargin
+		return
 	}
 
-	if config.Border.Width > 0 {
-		svg.AddOutline(terminal, config.Border.Width, config.Border.Color)
-
-		// NOTE: necessary so that we don't clip the outline.
-		terminalHeight -= (config.Border.Width * 2)
-		terminalWidth -= (config.Border.Width * 2)
-	}
-
-	if config.ShowLineNumbers {
-		if autoWidth {
-			terminalWidth += config.Font.Size * 3 * scale
-			imageWidth += config.Font.Size * 3 * scale
-		} else {
-			terminalWidth -= config.Font.Size * 3
-		}
+	err = os.WriteFile(config.Output, b, 0o600)
+	if err != nil {
+		printErrorFatal("Unable to write output", err)
 	}
+	printFilenameOutput(config.Output)
+}
 
-	if !autoHeight || !autoWidth {
-		svg.AddClipPath(image, "terminalMask",
-			config.Margin[left], config.Margin[top],
-			terminalWidth, terminalHeight-config.Padding[bottom])
+// render renders the input and encodes it into the output format.
+func render(config freeze.Config, input string) []byte {
+	doc, err := freeze.Render(context.Background(), config, strings.NewReader(input))
+	switch {
+	case errors.Is(err, freeze.ErrUnknownLanguage):
+		printErrorFatal("Language Unknown", errors.New("specify a language with the --language flag"))
+	case errors.Is(err, freeze.ErrNoInput):
+		printErrorFatal("No input", errors.New("check --lines is within bounds"))
+	case err != nil:
+		printErrorFatal("Something went wrong", err)
 	}
 
-	svg.Move(terminal, max(float64(config.Margin[left]), float64(config.Border.Width)/2), max(float64(config.Margin[top]), float64(config.Border.Width)/2))
-	svg.SetDimensions(image, imageWidth, imageHeight)
-	svg.SetDimensions(terminal, terminalWidth, terminalHeight)
-
-	if isAnsi {
-		parser := ansi.NewParser()
-		parser.SetHandler(ansi.Handler{
-			Print:     d.Print,
-			HandleCsi: d.CsiDispatch,
-			Execute:   d.Execute,
-		})
-		for _, line := range strings.Split(input, "\n") {
-			parser.Parse([]byte(line))
-			d.Execute(ansi.LF) // simulate a newline
+	var (
+		b          []byte
+		rasterizer freeze.Rasterizer
+	)
+	switch config.OutputFormat() {
+	case "png", "apng", "webp", "jpg", "jpeg", "avif", "gif":
+		var img image.Image
+		img, rasterizer, err = freeze.Rasterize(context.Background(), config, doc)
+		if err == nil {
+			b, err = freeze.EncodeImage(context.Background(), config, img)
 		}
+	default:
+		b, err = freeze.Encode(context.Background(), config, doc)
 	}
-
-	istty := isatty.IsTerminal(os.Stdout.Fd())
-
 	switch {
-	case strings.HasSuffix(config.Output, ".png"):
-		// use libsvg conversion.
-		svgConversionErr := libsvgConvert(doc, imageWidth, imageHeight, config.Output)
-		if svgConversionErr == nil {
-			printFilenameOutput(config.Output)
-			break
-		}
-
-		// could not convert with libsvg, try resvg
-		svgConversionErr = resvgConvert(doc, imageWidth, imageHeight, config.Output)
-		if svgConversionErr != nil {
-			printErrorFatal("Unable to convert SVG to PNG", svgConversionErr)
-		}
-		printFilenameOutput(config.Output)
-
-	default:
-		// output file specified.
-		if config.Output != "" {
-			err = doc.WriteToFile(config.Output)
-			if err != nil {
-				printErrorFatal("Unable to write output", err)
-			}
-			printFilenameOutput(config.Output)
-			return
-		}
-
-		// reading from stdin.
-		if config.Input == "" || config.Input == "-" {
-			if istty {
-				err = doc.WriteToFile(defaultOutputFilename)
-				printFilenameOutput(defaultOutputFilename)
-			} else {
-				_, err = doc.WriteTo(os.Stdout)
-			}
-			if err != nil {
-				printErrorFatal("Unable to write output", err)
-			}
-			return
-		}
+	case errors.Is(err, freeze.ErrUnknownFormat):
+		printErrorFatal("Unknown format", errors.New("use one of svg, png, webp, jpg, avif, gif, pdf, or html"))
+	case err != nil:
+		printErrorFatal("Unable to convert SVG", err)
+	}
 
-		// reading from file.
-		if istty {
-			config.Output = strings.TrimSuffix(filepath.Base(config.Input), filepath.Ext(config.Input)) + ".svg"
-			err = doc.WriteToFile(config.Output)
-			printFilenameOutput(config.Output)
-		} else {
-			_, err = doc.WriteTo(os.Stdout)
-		}
-		if err != nil {
-			printErrorFatal("Unable to write output", err)
-		}
+	// say so when the preferred rasterizer failed, since the image can look
+	// different.
+	if rasterizers, _ := freeze.Rasterizers(config.Renderer); rasterizer != nil && len(rasterizers) > 0 && rasterizers[0].Name() != rasterizer.Name() {
+		fmt.Fprintf(os.Stderr, "%s failed, rendered with %s\n", rasterizers[0].Name(), rasterizer.Name())
 	}
+	return b
 }
 
 var outputHeader = lipgloss.NewStyle().Foreground(lipgloss.Color("#F1F1F1")).Background(lipgloss.Color("#6C50FF")).Bold(true).Padding(0, 1).MarginRight(1).SetString("WROTE")
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	pngenc "image/png"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/ansi/iterm2"
+	"github.com/charmbracelet/x/ansi/kitty"
+	"github.com/charmbracelet/x/ansi/sixel"
+	"github.com/charmbracelet/x/term"
+	"golang.org/x/image/draw"
+
+	"github.com/charmbracelet/freeze/freeze"
+)
+
+// previewCellWidth is the width of a terminal cell, in pixels of an image
+// drawn at a scale of 1, used to size previews.
+const previewCellWidth = 8
+
+var errNoGraphics = errors.New("could not detect the graphics protocol of the terminal, set one with --preview.protocol")
+
+// preview renders the input and draws it in the terminal with an inline
+// graphics protocol.
+func preview(w io.Writer, config freeze.Config, input string) error {
+	config.Format = "png"
+	protocol := config.PreviewProtocol
+	if protocol == "" || protocol == "auto" {
+		protocol = detectGraphicsProtocol(os.Getenv)
+	}
+	if protocol == "" {
+		return errNoGraphics
+	}
+
+	ctx := context.Background()
+	doc, err := freeze.Render(ctx, config, strings.NewReader(input))
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+	img, _, err := freeze.Rasterize(ctx, config, doc)
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	// show the image about as wide as the code would be in the terminal,
+	// but never wider than the terminal.
+	columns := int(math.Ceil(float64(img.Bounds().Dx()) / config.Scale() / previewCellWidth))
+	if width, _, err := term.GetSize(os.Stdout.Fd()); err == nil && width > 0 {
+		columns = min(columns, width)
+	}
+
+	switch protocol {
+	case "kitty":
+		err = kitty.EncodeGraphics(w, img, &kitty.Options{
+			Action:       kitty.TransmitAndPut,
+			Transmission: kitty.Direct,
+			Format:       kitty.PNG,
+			Quite:        2,
+			Chunk:        true,
+			Columns:      columns,
+		})
+	case "iterm2":
+		var buf bytes.Buffer
+		if err = pngenc.Encode(&buf, img); err != nil {
+			return err //nolint: wrapcheck
+		}
+		_, err = io.WriteString(w, ansi.ITerm2(iterm2.File{
+			Name:    "freeze.png",
+			Size:    int64(buf.Len()),
+			Width:   iterm2.Cells(columns),
+			Inline:  true,
+			Content: []byte(base64.StdEncoding.EncodeToString(buf.Bytes())),
+		}))
+	case "sixel":
+		// sixels are drawn pixel for pixel, so the image has to be resized
+		// to fit instead.
+		bounds := img.Bounds()
+		width := min(bounds.Dx(), columns*previewCellWidth)
+		height := bounds.Dy() * width / bounds.Dx()
+		scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+
+		var buf bytes.Buffer
+		if err = new(sixel.Encoder).Encode(&buf, scaled); err != nil {
+			return err //nolint: wrapcheck
+		}
+		_, err = io.WriteString(w, ansi.SixelGraphics(0, 1, 0, buf.Bytes()))
+	default:
+		return fmt.Errorf("unknown graphics protocol %q", protocol)
+	}
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+	_, err = io.WriteString(w, "\n")
+	return err //nolint: wrapcheck
+}
+
+// detectGraphicsProtocol guesses the graphics protocol the terminal
+// supports from its environment variables. It returns an empty string when
+// it cannot tell.
+func detectGraphicsProtocol(getenv func(string) string) string {
+	termName := getenv("TERM")
+	termProgram := getenv("TERM_PROGRAM")
+	switch {
+	case getenv("KITTY_WINDOW_ID") != "", strings.Contains(termName, "kitty"),
+		termProgram == "ghostty", strings.Contains(termName, "ghostty"):
+		return "kitty"
+	case termProgram == "iTerm.app", getenv("LC_TERMINAL") == "iTerm2",
+		termProgram == "WezTerm", termProgram == "vscode":
+		return "iterm2"
+	case getenv("WT_SESSION") != "", getenv("KONSOLE_VERSION") != "",
+		strings.HasPrefix(termName, "foot"), strings.Contains(termName, "mlterm"):
+		return "sixel"
+	default:
+		return ""
+	}
+}
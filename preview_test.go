@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/freeze/freeze"
+)
+
+func TestDetectGraphicsProtocol(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{"TERM": "xterm-kitty"}, "kitty"},
+		{map[string]string{"TERM_PROGRAM": "ghostty"}, "kitty"},
+		{map[string]string{"TERM_PROGRAM": "iTerm.app"}, "iterm2"},
+		{map[string]string{"TERM_PROGRAM": "WezTerm"}, "iterm2"},
+		{map[string]string{"TERM": "foot"}, "sixel"},
+		{map[string]string{"WT_SESSION": "1"}, "sixel"},
+		{map[string]string{"TERM": "xterm-256color"}, ""},
+	}
+	for _, tc := range tests {
+		got := detectGraphicsProtocol(func(k string) string { return tc.env[k] })
+		if got != tc.want {
+			t.Errorf("%v: expected %q, got %q", tc.env, tc.want, got)
+		}
+	}
+}
+
+func TestPreview(t *testing.T) {
+	config := freeze.DefaultConfig()
+	config.Language = "go"
+	config.Renderer = "native"
+
+	prefixes := map[string]string{
+		"kitty":  "\x1b_G",
+		"iterm2": "\x1b]1337;File=",
+		"sixel":  "\x1bP0;1q",
+	}
+	for protocol, prefix := range prefixes {
+		config.PreviewProtocol = protocol
+		var buf bytes.Buffer
+		if err := preview(&buf, config, "package main\n"); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(buf.String(), prefix) {
+			t.Errorf("%s: expected output starting with %q, got %.16q", protocol, prefix, buf.String())
+		}
+	}
+}
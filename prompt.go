@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/freeze/freeze"
+)
+
+// promptLine returns the line of a terminal transcript showing the command
+// after the prompt of config.ExecutePrompt, or "" without a prompt.
+//
+// The prompt is a template with the placeholders {user}, {host}, {cwd}, the
+// directory the command runs in, and {dir}, the last element of {cwd}.
+func promptLine(config freeze.Config, command string) (string, error) {
+	if config.ExecutePrompt == "" {
+		return "", nil
+	}
+	sgr, err := sgrColor(config.ExecutePromptColor)
+	if err != nil {
+		return "", fmt.Errorf("invalid prompt color: %w", err)
+	}
+
+	cwd, err := filepath.Abs(config.ExecuteDir)
+	if err != nil {
+		return "", err //nolint: wrapcheck
+	}
+	prompt := strings.NewReplacer(
+		"{user}", username(),
+		"{host}", hostname(),
+		"{cwd}", homeRelative(cwd),
+		"{dir}", filepath.Base(cwd),
+	).Replace(config.ExecutePrompt)
+	command = strings.ReplaceAll(command, "\n", "\r\n")
+	return "\x1b[" + sgr + "m" + prompt + "\x1b[0m" + command + "\r\n", nil
+}
+
+func username() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// hostname returns the name of the machine, without its domain.
+func hostname() string {
+	name, _ := os.Hostname()
+	name, _, _ = strings.Cut(name, ".")
+	return name
+}
+
+// homeRelative returns the path with the home directory shortened to ~, like
+// shells show it.
+func homeRelative(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if rel, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return filepath.Join("~", rel)
+	}
+	return path
+}
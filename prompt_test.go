@@ -0,0 +1,51 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/freeze/freeze"
+)
+
+func TestPromptLine(t *testing.T) {
+	config := freeze.DefaultConfig()
+	config.ExecuteDir = "test/input"
+	config.ExecutePromptColor = "5"
+
+	line, err := promptLine(config, "ls")
+	if err != nil || line != "" {
+		t.Errorf("expected no prompt, got %q, %v", line, err)
+	}
+
+	config.ExecutePrompt = "{dir} $ "
+	line, err = promptLine(config, "ls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "\x1b[38;5;5minput $ \x1b[0mls\r\n"; line != want {
+		t.Errorf("expected %q, got %q", want, line)
+	}
+
+	config.ExecutePromptColor = "purple"
+	if _, err := promptLine(config, "ls"); err == nil {
+		t.Error("expected an error for an invalid color")
+	}
+}
+
+func TestHomeRelative(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	for _, tc := range []struct {
+		path, want string
+	}{
+		{home, "~"},
+		{filepath.Join(home, "src", "freeze"), filepath.Join("~", "src", "freeze")},
+		{home + "2", home + "2"},
+		{"/tmp", "/tmp"},
+	} {
+		if got := homeRelative(tc.path); got != tc.want {
+			t.Errorf("expected %q, got %q", tc.want, got)
+		}
+	}
+}
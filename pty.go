@@ -3,17 +3,61 @@ package main
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/caarlos0/go-shellwords"
 	"github.com/charmbracelet/x/term"
 	"github.com/charmbracelet/x/xpty"
+
+	"github.com/charmbracelet/freeze/freeze"
+	"github.com/charmbracelet/freeze/internal/screen"
 )
 
-func executeCommand(config Config) (string, error) {
+// finalFrameDelay is how long the last frame of an animation is shown.
+const finalFrameDelay = 2 * time.Second
+
+// executeCommand runs the command in a pty and returns its output.
+func executeCommand(config freeze.Config) (string, error) {
+	return runCommand(config, nil)
+}
+
+// recordCommand runs the command in a pty and returns a frame for every
+// change of its output, sampled every config.AnimateInterval.
+func recordCommand(config freeze.Config) ([]freeze.Frame, error) {
+	var frames []freeze.Frame
+	var last time.Time
+	record := func(output string) {
+		now := time.Now()
+		if output == "" {
+			return
+		}
+		if n := len(frames); n > 0 {
+			if frames[n-1].Input == output {
+				return
+			}
+			frames[n-1].Delay = now.Sub(last)
+		}
+		frames = append(frames, freeze.Frame{Input: output, Delay: finalFrameDelay})
+		last = now
+	}
+
+	output, err := runCommand(config, record)
+	record(output)
+	return frames, err
+}
+
+// runCommand runs the command in a pty and returns its output. While the
+// command runs, sample is called with the output so far every
+// config.AnimateInterval.
+func runCommand(config freeze.Config, sample func(output string)) (output string, err error) {
 	args, err := shellwords.Parse(config.Execute)
 	if err != nil {
 		return "", fmt.Errorf("could not execute: %w", err)
@@ -22,10 +66,53 @@ func executeCommand(config Config) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), config.ExecuteTimeout)
 	defer cancel()
 
-	width, height, err := term.GetSize(os.Stdout.Fd())
+	env, err := commandEnv(config)
 	if err != nil {
-		width = 80
-		height = 24
+		return "", fmt.Errorf("could not execute: %w", err)
+	}
+
+	stderrColor, err := sgrColor(config.ExecuteStderrColor)
+	if err != nil {
+		return "", fmt.Errorf("invalid stderr color: %w", err)
+	}
+
+	prompt, err := promptLine(config, config.Execute)
+	if err != nil {
+		return "", fmt.Errorf("could not execute: %w", err)
+	}
+
+	var steps []scriptStep
+	if config.ExecuteScript != "" {
+		f, err := os.Open(config.ExecuteScript)
+		if err != nil {
+			return "", fmt.Errorf("could not read script: %w", err)
+		}
+		steps, err = parseScript(f)
+		_ = f.Close()
+		if err != nil {
+			return "", fmt.Errorf("invalid script: %w", err)
+		}
+	}
+
+	width, height := terminalSize(config)
+
+	var rec *castRecorder
+	if config.ExecuteRecord != "" {
+		rec, err = newCastRecorder(config.ExecuteRecord, castHeader{
+			Width:   width,
+			Height:  height,
+			Command: config.Execute,
+			Env:     castEnv(env),
+		})
+		if err != nil {
+			return "", fmt.Errorf("could not record: %w", err)
+		}
+		// closed once the pty is, with all of the output recorded.
+		defer func() {
+			if cerr := rec.Close(); cerr != nil && err == nil {
+				err = fmt.Errorf("could not record: %w", cerr)
+			}
+		}()
 	}
 
 	pty, err := xpty.NewPty(width, height)
@@ -35,19 +122,192 @@ func executeCommand(config Config) (string, error) {
 	defer func() { _ = pty.Close() }()
 
 	cmd := exec.CommandContext(ctx, args[0], args[1:]...) //nolint: gosec
+	cmd.Env = env
+	cmd.Dir = config.ExecuteDir
+
+	var out syncBuffer
+	var w io.Writer = &out
+	if rec != nil {
+		w = io.MultiWriter(&out, rec)
+	}
+	// the prompt is shown as if the command was typed after it.
+	_, _ = io.WriteString(w, prompt)
+	if config.ExecuteStderr {
+		if runtime.GOOS == "windows" {
+			return "", errors.New("could not execute: --execute.stderr isn't supported on Windows")
+		}
+		// stderr is copied until the command and its children close it.
+		cmd.Stderr = newStderrWriter(w, stderrColor, config.ExecuteStderrMarker)
+		cmd.WaitDelay = time.Second
+	}
+
 	if err := pty.Start(cmd); err != nil {
 		return "", fmt.Errorf("could not execute: %w", err)
 	}
 
-	var out bytes.Buffer
-	var errorOut bytes.Buffer
+	copied := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(w, pty)
+		close(copied)
+	}()
+
+	done := make(chan error, 1)
 	go func() {
-		_, _ = io.Copy(&out, pty)
-		errorOut.Write(out.Bytes())
+		done <- xpty.WaitProcess(ctx, cmd)
 	}()
 
-	if err := xpty.WaitProcess(ctx, cmd); err != nil {
-		return errorOut.String(), fmt.Errorf("could not execute: %w", err)
+	// the script ends with a capture, or nil when it waits for the exit.
+	var scripted chan scriptResult
+	if len(steps) > 0 {
+		scripted = make(chan scriptResult, 1)
+		go func() {
+			capture, err := runScript(ctx, steps, pty, func() string {
+				return screen.Text(out.String(), width, height)
+			})
+			scripted <- scriptResult{capture, err}
+		}()
+	}
+
+	var tick <-chan time.Time
+	if sample != nil && config.AnimateInterval > 0 {
+		ticker := time.NewTicker(config.AnimateInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-tick:
+			sample(out.String())
+		case result := <-scripted:
+			scripted = nil
+			if result.err != nil {
+				return out.String(), fmt.Errorf("could not run script: %w", result.err)
+			}
+			if result.capture {
+				// the command is stopped once we return.
+				return out.String(), nil
+			}
+		case err := <-done:
+			drain(&out, copied)
+			var exitErr *exec.ExitError
+			if config.ExecuteExitStatus && ctx.Err() == nil && (err == nil || errors.As(err, &exitErr)) {
+				status := exitStatus(cmd.ProcessState.String(), cmd.ProcessState.Success(), stderrColor)
+				return out.String() + footer(out.String(), status), nil
+			}
+			if err != nil {
+				return out.String(), fmt.Errorf("could not execute: %w", err)
+			}
+			return out.String(), nil
+		}
+	}
+}
+
+// scriptResult is how the script of a command ended.
+type scriptResult struct {
+	capture bool
+	err     error
+}
+
+// cleanEnv are the variables kept from our environment in a clean one.
+// NO_COLOR isn't one of them, so commands print colors whatever our terminal
+// prefers; set it with --execute.env to capture them without.
+var cleanEnv = []string{"PATH", "HOME", "USER", "LANG", "TMPDIR"}
+
+// commandEnv returns the environment of the command: ours, or a clean one
+// with a fixed terminal, with the variables of config.ExecuteEnv on top.
+func commandEnv(config freeze.Config) ([]string, error) {
+	env := os.Environ()
+	if config.ExecuteCleanEnv {
+		env = []string{"TERM=xterm-256color", "COLORTERM=truecolor"}
+		for _, key := range cleanEnv {
+			if v, ok := os.LookupEnv(key); ok {
+				env = append(env, key+"="+v)
+			}
+		}
+	}
+	for _, v := range config.ExecuteEnv {
+		if key, _, ok := strings.Cut(v, "="); !ok || key == "" {
+			return nil, fmt.Errorf("invalid environment variable %q, use KEY=VALUE", v)
+		}
+	}
+	// later variables win over earlier ones with the same key.
+	return append(env, config.ExecuteEnv...), nil
+}
+
+// terminalSize returns the size of the terminal commands run in: the size
+// of this one, unless config sets it.
+func terminalSize(config freeze.Config) (width, height int) {
+	width, height, err := term.GetSize(os.Stdout.Fd())
+	if err != nil {
+		width = 80
+		height = 24
+	}
+	if config.ExecuteCols > 0 {
+		width = config.ExecuteCols
+	}
+	if config.ExecuteRows > 0 {
+		height = config.ExecuteRows
+	}
+	return width, height
+}
+
+// footer returns the line to add below the output, on a line of its own.
+func footer(output, line string) string {
+	if output != "" && !strings.HasSuffix(output, "\n") {
+		return "\r\n" + line
+	}
+	return line
+}
+
+// castEnv returns the variables of env a recording keeps, to replay it in a
+// similar terminal.
+func castEnv(env []string) map[string]string {
+	kept := map[string]string{}
+	for _, v := range env {
+		key, value, _ := strings.Cut(v, "=")
+		if key == "TERM" || key == "SHELL" {
+			kept[key] = value
+		}
+	}
+	return kept
+}
+
+// drain waits for the output of an exited command that is still in the pty
+// to be copied, until the copy ends or the output stops growing.
+func drain(out *syncBuffer, copied <-chan struct{}) {
+	const idle = 50 * time.Millisecond
+	n := -1
+	for n != out.Len() {
+		n = out.Len()
+		select {
+		case <-copied:
+			return
+		case <-time.After(idle):
+		}
 	}
-	return out.String(), nil
+}
+
+// syncBuffer is a buffer that can be read while the pty is written to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p) //nolint: wrapcheck
+}
+
+func (b *syncBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Len()
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
 }
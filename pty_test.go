@@ -0,0 +1,39 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/freeze/freeze"
+)
+
+func TestCommandEnvClean(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin")
+	t.Setenv("NO_COLOR", "1")
+	t.Setenv("FREEZE", "frozen")
+
+	config := freeze.Config{ExecuteCleanEnv: true}
+	env, err := commandEnv(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"TERM=xterm-256color", "COLORTERM=truecolor", "PATH=/usr/bin"} {
+		if !slices.Contains(env, v) {
+			t.Errorf("expected %s in %q", v, env)
+		}
+	}
+	for _, v := range []string{"NO_COLOR=1", "FREEZE=frozen"} {
+		if slices.Contains(env, v) {
+			t.Errorf("expected no %s in %q", v, env)
+		}
+	}
+
+	config.ExecuteEnv = []string{"NO_COLOR=1"}
+	env, err = commandEnv(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(env, "NO_COLOR=1") {
+		t.Errorf("expected NO_COLOR from --execute.env in %q", env)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scriptPollInterval is how often wait steps look at the screen.
+const scriptPollInterval = 50 * time.Millisecond
+
+// scriptStep is a line of an --execute.script:
+//
+//	type hello world     types the text, "quoted" for escapes like \n
+//	key ctrl+c enter     presses the keys
+//	sleep 500ms          waits for the duration
+//	wait Hello, \w+      waits for the screen to match the regular expression
+//	capture              captures the screen without waiting for the exit
+//
+// Empty lines and lines starting with # are skipped.
+type scriptStep struct {
+	line    int
+	command string
+	input   string
+	sleep   time.Duration
+	pattern *regexp.Regexp
+}
+
+// keys are the sequences terminals send for named keys.
+var keys = map[string]string{
+	"enter":     "\r",
+	"tab":       "\t",
+	"space":     " ",
+	"backspace": "\x7f",
+	"esc":       "\x1b",
+	"up":        "\x1b[A",
+	"down":      "\x1b[B",
+	"right":     "\x1b[C",
+	"left":      "\x1b[D",
+	"home":      "\x1b[H",
+	"end":       "\x1b[F",
+	"insert":    "\x1b[2~",
+	"delete":    "\x1b[3~",
+	"pgup":      "\x1b[5~",
+	"pgdown":    "\x1b[6~",
+	"f1":        "\x1bOP",
+	"f2":        "\x1bOQ",
+	"f3":        "\x1bOR",
+	"f4":        "\x1bOS",
+}
+
+// parseScript reads the steps of a script.
+func parseScript(r io.Reader) ([]scriptStep, error) {
+	var steps []scriptStep
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		command, arg, _ := strings.Cut(line, " ")
+		arg = strings.TrimSpace(arg)
+		step := scriptStep{line: n, command: command}
+
+		var err error
+		switch command {
+		case "type":
+			step.input = arg
+			if strings.HasPrefix(arg, `"`) {
+				step.input, err = strconv.Unquote(arg)
+			}
+		case "key":
+			step.input, err = keySequence(strings.Fields(arg))
+		case "sleep":
+			step.sleep, err = time.ParseDuration(arg)
+		case "wait":
+			step.pattern, err = regexp.Compile(arg)
+		case "capture":
+		default:
+			err = fmt.Errorf("unknown command %q, use type, key, sleep, wait or capture", command)
+		}
+		if err == nil && arg == "" && command != "capture" {
+			err = fmt.Errorf("%s needs an argument", command)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		steps = append(steps, step)
+	}
+	return steps, scanner.Err() //nolint: wrapcheck
+}
+
+// keySequence returns the input of pressing the keys in order. Besides the
+// named keys, keys are single characters, optionally with ctrl+ or alt+.
+func keySequence(names []string) (string, error) {
+	var b strings.Builder
+	for _, name := range names {
+		key := strings.ToLower(name)
+		alt := strings.HasPrefix(key, "alt+")
+		key = strings.TrimPrefix(key, "alt+")
+		ctrl := strings.HasPrefix(key, "ctrl+")
+		key = strings.TrimPrefix(key, "ctrl+")
+
+		seq, ok := keys[key]
+		if !ok && len(key) == 1 {
+			seq, ok = key, true
+			if !ctrl {
+				// keep the case of plain characters.
+				seq = name[len(name)-1:]
+			}
+		}
+		if ctrl {
+			if len(key) != 1 || key[0] < 'a' || key[0] > 'z' {
+				ok = false
+			} else {
+				seq = string(rune(key[0] - 'a' + 1))
+			}
+		}
+		if !ok {
+			return "", fmt.Errorf("unknown key %q", name)
+		}
+		if alt {
+			seq = "\x1b" + seq
+		}
+		b.WriteString(seq)
+	}
+	return b.String(), nil
+}
+
+// runScript runs the steps, writing input to w and matching wait steps
+// against the screen. It returns true when the script asks to capture the
+// screen.
+func runScript(ctx context.Context, steps []scriptStep, w io.Writer, screen func() string) (bool, error) {
+	for _, step := range steps {
+		var err error
+		switch step.command {
+		case "type", "key":
+			_, err = io.WriteString(w, step.input)
+		case "sleep":
+			err = sleep(ctx, step.sleep)
+		case "wait":
+			for !step.pattern.MatchString(screen()) {
+				if err = sleep(ctx, scriptPollInterval); err != nil {
+					err = fmt.Errorf("waiting for %q: %w", step.pattern, err)
+					break
+				}
+			}
+		case "capture":
+			return true, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("line %d: %w", step.line, err)
+		}
+	}
+	return false, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err() //nolint: wrapcheck
+	case <-time.After(d):
+		return nil
+	}
+}
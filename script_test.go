@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseScript(t *testing.T) {
+	script := `
+# log in
+type admin
+type "secret\n"
+key ctrl+c alt+x Q up enter
+sleep 10ms
+wait \$ $
+capture
+`
+	steps, err := parseScript(strings.NewReader(script))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 6 {
+		t.Fatalf("expected 6 steps, got %d", len(steps))
+	}
+	for _, tc := range []struct{ got, want string }{
+		{steps[0].input, "admin"},
+		{steps[1].input, "secret\n"},
+		{steps[2].input, "\x03\x1bxQ\x1b[A\r"},
+		{steps[3].sleep.String(), "10ms"},
+		{steps[4].pattern.String(), `\$ $`},
+		{steps[5].command, "capture"},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("expected %q, got %q", tc.want, tc.got)
+		}
+	}
+	if steps[2].line != 5 {
+		t.Errorf("expected step on line 5, got %d", steps[2].line)
+	}
+}
+
+func TestParseScriptErrors(t *testing.T) {
+	for _, script := range []string{
+		"press enter",
+		"type",
+		"key ctrl+enter",
+		"key hyper",
+		"sleep soon",
+		"wait (",
+		`type "unterminated`,
+	} {
+		t.Run(script, func(t *testing.T) {
+			if _, err := parseScript(strings.NewReader(script)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	steps, err := parseScript(strings.NewReader("type hello\nkey enter\nwait hello\ncapture\ntype ignored"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var input bytes.Buffer
+	polls := 0
+	screen := func() string {
+		polls++
+		if polls < 3 {
+			return ""
+		}
+		return input.String()
+	}
+	capture, err := runScript(context.Background(), steps, &input, screen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !capture {
+		t.Error("expected the script to capture")
+	}
+	if got := input.String(); got != "hello\r" {
+		t.Errorf("expected input %q, got %q", "hello\r", got)
+	}
+	if polls != 3 {
+		t.Errorf("expected 3 polls of the screen, got %d", polls)
+	}
+}
+
+func TestRunScriptTimeout(t *testing.T) {
+	steps, err := parseScript(strings.NewReader("wait never"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := runScript(ctx, steps, &bytes.Buffer{}, func() string { return "" }); err == nil {
+		t.Error("expected the wait to time out")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// stderrWriter writes the stderr of a command in its own color, with a
+// marker at the start of its lines. stderr doesn't go through the pty, so
+// its newlines are turned into the carriage returns and line feeds the pty
+// would send.
+type stderrWriter struct {
+	w      io.Writer
+	sgr    string
+	marker string
+	// midLine is whether the last write ended in the middle of a line.
+	midLine bool
+}
+
+// newStderrWriter returns a writer of stderr to w in the color of the SGR
+// parameters sgr.
+func newStderrWriter(w io.Writer, sgr, marker string) *stderrWriter {
+	return &stderrWriter{w: w, sgr: "\x1b[" + sgr + "m", marker: marker}
+}
+
+func (s *stderrWriter) Write(p []byte) (int, error) {
+	var b strings.Builder
+	for line := range strings.Lines(string(p)) {
+		text, newline := strings.CutSuffix(line, "\n")
+		text = strings.TrimSuffix(text, "\r")
+		b.WriteString(s.sgr)
+		if !s.midLine {
+			b.WriteString(s.marker)
+		}
+		// keep the color after the command resets its own.
+		text = strings.ReplaceAll(text, "\x1b[0m", "\x1b[0m"+s.sgr)
+		text = strings.ReplaceAll(text, "\x1b[m", "\x1b[m"+s.sgr)
+		b.WriteString(text + "\x1b[0m")
+		if newline {
+			b.WriteString("\r\n")
+		}
+		s.midLine = !newline
+	}
+	if _, err := io.WriteString(s.w, b.String()); err != nil {
+		return 0, err //nolint: wrapcheck
+	}
+	return len(p), nil
+}
+
+// sgrColor returns the SGR parameters of a foreground color: #rrggbb or the
+// index of one of the 256 colors of the palette.
+func sgrColor(c string) (string, error) {
+	if n, err := strconv.Atoi(c); err == nil && n >= 0 && n < 256 {
+		return "38;5;" + c, nil
+	}
+	var r, g, b uint8
+	if len(c) == 7 {
+		if _, err := fmt.Sscanf(c, "#%02x%02x%02x", &r, &g, &b); err == nil {
+			return fmt.Sprintf("38;2;%d;%d;%d", r, g, b), nil
+		}
+	}
+	return "", fmt.Errorf("invalid color %q, use #rrggbb or a palette index from 0 to 255", c)
+}
+
+// exitStatus returns the footer line showing how a command exited: dim when
+// it succeeded, and in the color of the SGR parameters sgr when it failed.
+func exitStatus(status string, success bool, sgr string) string {
+	if success {
+		sgr = "2"
+	}
+	return "\x1b[" + sgr + "m" + status + "\x1b[0m\r\n"
+}
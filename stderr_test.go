@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestStderrWriter(t *testing.T) {
+	var b strings.Builder
+	w := newStderrWriter(&b, "31", "! ")
+	for _, p := range []string{"first\nsec", "ond \x1b[1mbold\x1b[0m\n"} {
+		if _, err := w.Write([]byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := "\x1b[31m! first\x1b[0m\r\n" +
+		"\x1b[31m! sec\x1b[0m" +
+		"\x1b[31mond \x1b[1mbold\x1b[0m\x1b[31m\x1b[0m\r\n"
+	if got := b.String(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSGRColor(t *testing.T) {
+	for _, tc := range []struct {
+		color, want string
+	}{
+		{"1", "38;5;1"},
+		{"208", "38;5;208"},
+		{"#ff5f87", "38;2;255;95;135"},
+		{"256", ""},
+		{"red", ""},
+		{"#ff5f8", ""},
+	} {
+		got, err := sgrColor(tc.color)
+		if tc.want == "" && err == nil {
+			t.Errorf("expected an error for %q", tc.color)
+		}
+		if got != tc.want {
+			t.Errorf("expected %q for %q, got %q", tc.want, tc.color, got)
+		}
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	for _, tc := range []struct {
+		command, want string
+	}{
+		{"true", "\x1b[2mexit status 0\x1b[0m\r\n"},
+		{"false", "\x1b[31mexit status 1\x1b[0m\r\n"},
+	} {
+		cmd := exec.Command(tc.command)
+		_ = cmd.Run()
+		if got := exitStatus(cmd.ProcessState.String(), cmd.ProcessState.Success(), "31"); got != tc.want {
+			t.Errorf("expected %q, got %q", tc.want, got)
+		}
+	}
+
+	if got := footer("no newline", "status"); got != "\r\nstatus" {
+		t.Errorf("expected the footer on its own line, got %q", got)
+	}
+}
@@ -116,6 +116,6 @@ func GetDimensions(element *etree.Element) (int, int) {
 // e.g. "500px" -> 500.
 func dimensionToInt(px string) int {
 	d := strings.TrimSuffix(px, "px")
-	v, _ := strconv.ParseInt(d, 10, 64)
+	v, _ := strconv.ParseFloat(d, 64)
 	return int(v)
 }
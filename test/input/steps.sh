@@ -0,0 +1,6 @@
+# each command runs in the same shell, after the last one.
+cd input
+ls *.go
+export GREETING=hello
+echo "$GREETING from $(basename "$PWD")"
+test -f missing.txt
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/xpty"
+
+	"github.com/charmbracelet/freeze/freeze"
+)
+
+// transcriptPollInterval is how often the output of the shell is checked for
+// the end of a command.
+const transcriptPollInterval = 20 * time.Millisecond
+
+// transcriptMarker starts the sequence the shell prints after every command,
+// followed by the number of the command, its exit status and the working
+// directory. It's an OSC sequence terminals ignore, and it's removed from
+// the transcript.
+const transcriptMarker = "\x1b]7777;freeze;"
+
+// defaultTranscriptPrompt is the prompt of transcripts without
+// --execute.prompt, since commands can't be told apart without one.
+const defaultTranscriptPrompt = "$ "
+
+// parseTranscript reads the commands of a transcript, one per line. Lines
+// ending with a backslash continue on the next one, and empty lines and
+// lines starting with # are skipped.
+func parseTranscript(r io.Reader) ([]string, error) {
+	var commands []string
+	var command strings.Builder
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if command.Len() == 0 {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+		}
+		command.WriteString(line)
+		if strings.HasSuffix(line, `\`) {
+			command.WriteString("\n")
+			continue
+		}
+		commands = append(commands, command.String())
+		command.Reset()
+	}
+	if command.Len() > 0 {
+		commands = append(commands, strings.TrimSuffix(command.String(), "\\\n"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+	if len(commands) == 0 {
+		return nil, errors.New("no commands")
+	}
+	return commands, nil
+}
+
+// runTranscript runs the commands of config.Transcript one after the other
+// in a shell, so they share its state, and returns the transcript of the
+// session: every command after its prompt, followed by its output. Every
+// command has config.ExecuteTimeout to finish.
+func runTranscript(config freeze.Config) (string, error) {
+	// the shell runs every command on its own pty, which only it reads
+	// from.
+	for _, flag := range []struct {
+		name string
+		set  bool
+	}{
+		{"--execute.stderr", config.ExecuteStderr},
+		{"--execute.record", config.ExecuteRecord != ""},
+		{"--execute.script", config.ExecuteScript != ""},
+	} {
+		if flag.set {
+			return "", fmt.Errorf("%s can't be used with --transcript", flag.name)
+		}
+	}
+
+	f, err := os.Open(config.Transcript)
+	if err != nil {
+		return "", fmt.Errorf("could not read transcript: %w", err)
+	}
+	commands, err := parseTranscript(f)
+	_ = f.Close()
+	if err != nil {
+		return "", fmt.Errorf("invalid transcript: %w", err)
+	}
+
+	env, err := commandEnv(config)
+	if err != nil {
+		return "", fmt.Errorf("could not execute: %w", err)
+	}
+	stderrColor, err := sgrColor(config.ExecuteStderrColor)
+	if err != nil {
+		return "", fmt.Errorf("invalid stderr color: %w", err)
+	}
+	if config.ExecutePrompt == "" {
+		config.ExecutePrompt = defaultTranscriptPrompt
+	}
+
+	pty, err := xpty.NewPty(terminalSize(config))
+	if err != nil {
+		return "", fmt.Errorf("could not execute: %w", err)
+	}
+	defer func() { _ = pty.Close() }()
+
+	cmd := exec.Command(config.TranscriptShell) //nolint: gosec
+	// the shell shows no prompts of its own.
+	cmd.Env = append(env, "PS1=", "PS2=", "ENV=")
+	cmd.Dir = config.ExecuteDir
+	if err := pty.Start(cmd); err != nil {
+		return "", fmt.Errorf("could not execute: %w", err)
+	}
+
+	var out syncBuffer
+	go func() { _, _ = io.Copy(&out, pty) }()
+	done := make(chan error, 1)
+	go func() { done <- xpty.WaitProcess(context.Background(), cmd) }()
+	defer func() { _ = cmd.Process.Kill() }()
+
+	s := &shellSession{pty: pty, out: &out, done: done, timeout: config.ExecuteTimeout}
+	// commands aren't echoed, and what the shell printed before is dropped.
+	if _, err := s.run(0, "stty -echo"); err != nil {
+		return "", fmt.Errorf("could not start %s: %w", config.TranscriptShell, err)
+	}
+
+	var b strings.Builder
+	cwd := config.ExecuteDir
+	for i, command := range commands {
+		promptConfig := config
+		promptConfig.ExecuteDir = cwd
+		prompt, err := promptLine(promptConfig, command)
+		if err != nil {
+			return "", fmt.Errorf("could not execute: %w", err)
+		}
+		b.WriteString(prompt)
+
+		// commands read from /dev/null, so they can't take the line that
+		// prints the marker from the shell.
+		result, err := s.run(i+1, "{ "+command+"\n} </dev/null")
+		b.WriteString(result.output)
+		if err != nil {
+			return b.String(), fmt.Errorf("could not execute %q: %w", command, err)
+		}
+		// like shells, start the next prompt on a line of its own.
+		b.WriteString(footer(result.output, ""))
+		if config.ExecuteExitStatus {
+			status := fmt.Sprintf("exit status %d", result.status)
+			b.WriteString(exitStatus(status, result.status == 0, stderrColor))
+		}
+		cwd = result.cwd
+	}
+	return b.String(), nil
+}
+
+// shellSession runs commands in a shell and reads their output.
+type shellSession struct {
+	pty     io.Writer
+	out     *syncBuffer
+	done    <-chan error
+	timeout time.Duration
+	// read is how much of the output has been read.
+	read int
+}
+
+// shellResult is the output and the state of the shell after a command.
+type shellResult struct {
+	output string
+	status int
+	cwd    string
+}
+
+// run runs the nth command and waits for the shell to print its marker.
+func (s *shellSession) run(n int, command string) (shellResult, error) {
+	_, err := fmt.Fprintf(s.pty, "%s\nprintf '\\033]7777;freeze;%d;%%d;%%s\\007' \"$?\" \"$PWD\"\n", command, n)
+	if err != nil {
+		return shellResult{}, err //nolint: wrapcheck
+	}
+
+	marker := transcriptMarker + strconv.Itoa(n) + ";"
+	timeout := time.After(s.timeout)
+	for {
+		output := s.out.String()[s.read:]
+		if i := strings.Index(output, marker); i >= 0 {
+			if j := strings.IndexByte(output[i:], '\a'); j >= 0 {
+				status, cwd, _ := strings.Cut(output[i+len(marker):i+j], ";")
+				code, _ := strconv.Atoi(status)
+				s.read += i + j + 1
+				return shellResult{output: output[:i], status: code, cwd: cwd}, nil
+			}
+		}
+
+		select {
+		case <-timeout:
+			return shellResult{output: output}, errors.New("timed out")
+		case <-s.done:
+			return shellResult{output: output}, errors.New("the shell exited")
+		case <-time.After(transcriptPollInterval):
+		}
+	}
+}
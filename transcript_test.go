@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/freeze/freeze"
+)
+
+func TestParseTranscript(t *testing.T) {
+	commands, err := parseTranscript(strings.NewReader(`
+# set up
+git status
+
+echo multi \
+  line
+echo "# not a comment"
+echo unfinished \`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"git status",
+		"echo multi \\\n  line",
+		`echo "# not a comment"`,
+		"echo unfinished ",
+	}
+	if strings.Join(commands, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %q, got %q", want, commands)
+	}
+
+	if _, err := parseTranscript(strings.NewReader("# only comments\n\n")); err == nil {
+		t.Error("expected an error without commands")
+	}
+}
+
+func TestRunTranscript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("transcripts need a posix shell")
+	}
+	path := filepath.Join(t.TempDir(), "steps.sh")
+	// cat would read the rest of the session if it had the pty as stdin.
+	if err := os.WriteFile(path, []byte("cat\necho after\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := freeze.DefaultConfig()
+	config.Transcript = path
+	config.TranscriptShell = "sh"
+	config.ExecutePromptColor = "5"
+	config.ExecuteStderrColor = "1"
+	config.ExecuteTimeout = 5 * time.Second
+	out, err := runTranscript(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "\x1b[38;5;5m$ \x1b[0mcat\r\n\x1b[38;5;5m$ \x1b[0mecho after\r\nafter\r\n"; out != want {
+		t.Errorf("expected %q, got %q", want, out)
+	}
+
+	for _, set := range []func(*freeze.Config){
+		func(c *freeze.Config) { c.ExecuteStderr = true },
+		func(c *freeze.Config) { c.ExecuteRecord = "demo.cast" },
+		func(c *freeze.Config) { c.ExecuteScript = "greet.script" },
+	} {
+		config := config
+		set(&config)
+		if _, err := runTranscript(config); err == nil || !strings.Contains(err.Error(), "can't be used with --transcript") {
+			t.Errorf("expected an error, got %v", err)
+		}
+	}
+}